@@ -12,12 +12,14 @@ import (
 	"io"
 	"os"
 	"os/signal"
+	"path/filepath"
 
 	"github.com/urfave/cli/v3"
 
 	"github.com/gomatic/renderizer/internal/app"
 	analyzecmd "github.com/gomatic/renderizer/internal/app/commands/analyze"
 	rendercmd "github.com/gomatic/renderizer/internal/app/commands/render"
+	testcmd "github.com/gomatic/renderizer/internal/app/commands/test"
 	versioncmd "github.com/gomatic/renderizer/internal/app/commands/version"
 	versiondomain "github.com/gomatic/renderizer/internal/domain/version"
 	"github.com/gomatic/renderizer/internal/variables"
@@ -43,8 +45,9 @@ func execute(args []string, stdin *os.File, stdout, stderr io.Writer) app.ExitSt
 	return run(ctx, args, stdin, stdout, stderr, piped(stdin))
 }
 
-// run tokenizes the arguments, builds the root render command with the analyze
-// and version subcommands, runs it, and returns the resulting exit code.
+// run tokenizes the arguments against the flags of the command tree, builds
+// the tree, runs it, reports any failure in the requested error format, and
+// returns the resulting exit code.
 func run(
 	ctx context.Context,
 	args []string,
@@ -54,10 +57,13 @@ func run(
 ) app.ExitStatus {
 	// Expose the version to templates as {{.env.RENDERIZER_VERSION}}.
 	_ = os.Setenv("RENDERIZER_VERSION", version)
-	tokens := variables.Tokenize(args[1:])
+	tokens := variables.Tokenize(args[1:], app.LongFlags(command(app.Runtime{})))
 	rt := app.Runtime{
-		Source:   stdin,
-		ReadFile: os.ReadFile,
+		Source:    stdin,
+		ReadFile:  os.ReadFile,
+		WriteFile: writeFile,
+		ReadDir:   os.ReadDir,
+		Glob:      filepath.Glob,
 		// Exists reports whether a path exists, for default-template discovery.
 		Exists: func(name string) bool {
 			_, err := os.Stat(name)
@@ -70,17 +76,35 @@ func run(
 		TimeFormat:        timeFormat,
 		IsPiped:           isPiped,
 	}
+	root := command(rt)
+	root.Writer = stdout
+	root.ErrWriter = stderr
+	err := root.Run(ctx, append([]string{root.Name}, tokens.Args...))
+	// A report that cannot be written leaves the exit status to tell.
+	_ = app.Report(stderr, app.ErrorFormat(root.String(app.ErrorFormatFlag)), err)
+	return app.ExitCode(err)
+}
+
+// command returns the root render command of rt, with the analyze, test, and
+// version subcommands.
+func command(rt app.Runtime) *cli.Command {
 	root := rendercmd.Command(rt)
 	root.Version = version
 	root.EnableShellCompletion = true
-	root.Writer = stdout
-	root.ErrWriter = stderr
 	root.Commands = []*cli.Command{
 		analyzecmd.Command(rt),
+		testcmd.Command(rt, rendercmd.Command),
 		versioncmd.Command(versiondomain.AppName(root.Name), versiondomain.Build(version)),
 	}
-	err := root.Run(ctx, append([]string{root.Name}, tokens.Args...))
-	return app.ExitCode(err)
+	return root
+}
+
+// writeFile writes a rendered output file, creating its directory first.
+func writeFile(name string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(name, data, 0o644)
 }
 
 // piped reports whether f is a pipe rather than a terminal, so stdin is used
@@ -3,6 +3,7 @@ package main
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"os"
 	"path/filepath"
 	"strings"
@@ -274,3 +275,213 @@ func TestMainEntry(t *testing.T) {
 	main()
 	assert.Equal(t, 0, code)
 }
+
+func TestErrorFormatJSON(t *testing.T) {
+	_, stderr, code := exec(t, "ok\n{{ .Missing.Name }}", false, "--stdin", "--error-format=json")
+	require.Equal(t, app.ExitStatus(8), code)
+
+	var report map[string]any
+	require.NoError(t, json.Unmarshal([]byte(stderr), &report), "stderr: %s", stderr)
+	assert.Equal(t, "execute", report["stage"])
+	assert.InDelta(t, 8, report["status"], 0)
+	assert.InDelta(t, 2, report["line"], 0)
+	assert.Equal(t, "{{ .Missing.Name }}", report["source"])
+	assert.Equal(t, ".Missing.Name", report["path"])
+	assert.NotEmpty(t, report["causes"])
+}
+
+func TestErrorFormatTextDiagnosesTheFailure(t *testing.T) {
+	_, stderr, code := exec(t, "Hello, {{ .Nmae }}!", false, "--stdin", "--name=World")
+	assert.Equal(t, app.ExitStatus(8), code)
+	assert.Equal(t, `stdin:1:11: execute error: map has no entry for key "Nmae"
+ 1 | Hello, {{ .Nmae }}!
+   |           ^
+did you mean `+"`Name`"+`?
+`, stderr)
+}
+
+func TestAnalyzeDiagnosesAnUnknownFunction(t *testing.T) {
+	_, stderr, code := exec(t, "{{ uper .Name }}", true, "analyze")
+	assert.Equal(t, app.ExitStatus(4), code)
+	assert.Contains(t, stderr, "did you mean `upper`?")
+}
+
+func TestKeepGoing(t *testing.T) {
+	dir := t.TempDir()
+	good := filepath.Join(dir, "good.tmpl")
+	bad := filepath.Join(dir, "bad.tmpl")
+	require.NoError(t, os.WriteFile(good, []byte("Hello {{.Name}}"), 0o644))
+	require.NoError(t, os.WriteFile(bad, []byte("{{ .Nmae }}"), 0o644))
+
+	out, stderr, code := exec(t, "", false, "--keep-going", bad, good, "--name=X")
+	assert.Equal(t, app.ExitStatus(8), code)
+	assert.Equal(t, "Hello X\n", out)
+	assert.Contains(t, stderr, "1 of the templates failed:")
+	assert.Contains(t, stderr, bad)
+}
+
+func TestLimits(t *testing.T) {
+	for _, tc := range []struct {
+		flag string
+		want app.ExitStatus
+	}{
+		{flag: "--timeout=10ms", want: 16},
+		{flag: "--max-output=1024", want: 17},
+		{flag: "--max-iterations=1000", want: 18},
+	} {
+		t.Run(tc.flag, func(t *testing.T) {
+			_, stderr, code := exec(t, "{{ range 100000000 }}x{{ end }}", false, "--stdin", tc.flag)
+			assert.Equal(t, tc.want, code)
+			assert.Contains(t, stderr, "renderizer: template")
+		})
+	}
+}
+
+func TestDelims(t *testing.T) {
+	out, _, code := exec(t, "[[ .Name ]]: {{ .Kept }}", false, "--stdin", "--delims=[[,]]", "--name=x")
+	require.Equal(t, app.ExitStatus(0), code)
+	assert.Equal(t, "x: {{ .Kept }}\n", out)
+
+	out, _, code = exec(t, "[[ .Name ]] {{ .Kept }}", true, "analyze", "--delims", "[[,]]")
+	require.Equal(t, app.ExitStatus(0), code)
+	assert.Equal(t, "Name: \"\"\n", out)
+}
+
+func TestHTML(t *testing.T) {
+	out, _, code := exec(t, "<p>{{ .Name }}</p>", false, "--stdin", "--html", "--name=<script>")
+	require.Equal(t, app.ExitStatus(0), code)
+	assert.Equal(t, "<p>&lt;script&gt;</p>\n", out)
+
+	_, stderr, code := exec(t, `<a {{ if .Name }}href{{ end }}="x">`, false, "--stdin", "--html", "--name=x")
+	assert.Equal(t, app.ExitStatus(4), code)
+	assert.Contains(t, stderr, "stdin:1:")
+}
+
+func TestLibrary(t *testing.T) {
+	dir := t.TempDir()
+	partials := filepath.Join(dir, "partials")
+	require.NoError(t, os.Mkdir(partials, 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(partials, "labels.tmpl"),
+		[]byte(`{{ define "labels" }}app={{ .Name }}{{ end }}`), 0o644))
+
+	out, _, code := exec(t, `{{ template "labels" . }}`, false, "--stdin", "--lib="+partials, "--name=web")
+	require.Equal(t, app.ExitStatus(0), code)
+	assert.Equal(t, "app=web\n", out)
+
+	_, _, code = exec(t, "x", false, "--stdin", "--lib="+filepath.Join(dir, "missing"))
+	assert.Equal(t, app.ExitStatus(2), code)
+}
+
+func TestFrontMatter(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	path := filepath.Join(dir, "t.tmpl")
+	output := filepath.Join(dir, "out", "web.txt")
+	front := "---\ndefaults:\n  Port: 80\noutput: " + filepath.Join("out", "{{ .Name }}.txt") + "\n---\n"
+	require.NoError(t, os.WriteFile(path, []byte(front+"{{ .Name }}:{{ .Port }}"), 0o644))
+
+	out, _, code := exec(t, "", false, path, "--name=web")
+	require.Equal(t, app.ExitStatus(0), code)
+	assert.Empty(t, out)
+	written, err := os.ReadFile(output)
+	require.NoError(t, err)
+	assert.Equal(t, "web:80", string(written))
+
+	out, _, code = exec(t, "", false, "analyze", path)
+	require.Equal(t, app.ExitStatus(0), code)
+	assert.Equal(t, "Name: \"\"\nPort: 80\n", out)
+}
+
+func TestFileMarkers(t *testing.T) {
+	dir := t.TempDir()
+	t.Chdir(dir)
+	source := "{{ range .Apps }}---# file: " + filepath.Join("{{ . }}", "app.yaml") + "\nname: {{ . }}\n{{ end }}"
+
+	out, _, code := exec(t, source, false, "--stdin", "--apps=web", "--apps=db")
+	require.Equal(t, app.ExitStatus(0), code)
+	assert.Empty(t, out)
+	for _, name := range []string{"web", "db"} {
+		written, err := os.ReadFile(filepath.Join(dir, name, "app.yaml"))
+		require.NoError(t, err)
+		assert.Equal(t, "name: "+name+"\n", string(written))
+	}
+
+	_, stderr, code := exec(t, "---# file: "+filepath.Join(dir, "web", "app.yaml")+"\nname: x\n", false, "--stdin")
+	assert.Equal(t, app.ExitStatus(1), code)
+	assert.Contains(t, stderr, "output path leaves the working directory")
+}
+
+func TestValidateOutput(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "deploy.yaml.tmpl")
+	require.NoError(t, os.WriteFile(path, []byte("kind: Pod\nname: {{ .Name }}\n"), 0o644))
+
+	out, _, code := exec(t, "", false, path, "--validate-output", "--name=web")
+	require.Equal(t, app.ExitStatus(0), code)
+	assert.Equal(t, "kind: Pod\nname: web\n\n", out)
+
+	_, stderr, code := exec(t, "", false, path, "--validate-output", "--name=web: db")
+	assert.Equal(t, app.ExitStatus(19), code)
+	assert.Contains(t, stderr, "validate error")
+	assert.Contains(t, stderr, path+":2")
+}
+
+func TestFormatOutputGo(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "main.go.tmpl")
+	require.NoError(t, os.WriteFile(path, []byte("package {{ .Name }}\nfunc f( ) {}\n"), 0o644))
+
+	out, stderr, code := exec(t, "", false, path, "--format-output", "--name=main")
+	require.Equal(t, app.ExitStatus(0), code, stderr)
+	assert.Equal(t, "package main\n\nfunc f() {}\n", out)
+}
+
+func TestSeedAndNow(t *testing.T) {
+	source := `{{ now | date "2006-01-02T15:04:05Z07:00" }} {{ uuidv4 }} {{ randAlphaNum 12 }}`
+	args := []string{"--stdin", "--testing", "--seed=42", "--now=2024-01-02T03:04:05Z"}
+
+	first, _, code := exec(t, source, false, args...)
+	require.Equal(t, app.ExitStatus(0), code)
+	assert.True(t, strings.HasPrefix(first, "2024-01-02T03:04:05Z "), first)
+	second, _, _ := exec(t, source, false, args...)
+	assert.Equal(t, first, second)
+	reseeded, _, _ := exec(t, source, false, "--stdin", "--testing", "--seed=43", "--now=2024-01-02T03:04:05Z")
+	assert.NotEqual(t, first, reseeded)
+}
+
+func TestInPlace(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "Makefile")
+	original := "all:\n\techo hi\n# renderizer:begin inline VERSION := {{ .Release }}\nVERSION := 1\n# renderizer:end\n"
+	require.NoError(t, os.WriteFile(path, []byte(original), 0o644))
+
+	_, stderr, code := exec(t, "", false, "--in-place="+path, "--check", "--release=2")
+	assert.Equal(t, app.ExitStatus(1), code)
+	assert.Contains(t, stderr, "generated region is out of date")
+
+	out, _, code := exec(t, "", false, "--in-place="+path, "--release=2")
+	require.Equal(t, app.ExitStatus(0), code)
+	assert.Empty(t, out)
+	rewritten, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, strings.Replace(original, "VERSION := 1\n", "VERSION := 2\n", 1), string(rewritten))
+
+	_, _, code = exec(t, "", false, "--in-place="+path, "--check", "--release=2")
+	assert.Equal(t, app.ExitStatus(0), code)
+}
+
+// TestGoldenExamples pins that every example renders what it expects.
+func TestGoldenExamples(t *testing.T) {
+	out, stderr, code := exec(t, "", false, "test", filepath.Join("..", "..", "examples"))
+	require.Equal(t, app.ExitStatus(0), code, stderr)
+	assert.Contains(t, out, "ok       functions\n")
+	assert.Contains(t, out, " passed, 0 failed, 0 updated\n")
+}
+
+// TestSubcommandFlagIsRenderVariable pins that a flag only a subcommand
+// defines is still a variable of the render command.
+func TestSubcommandFlagIsRenderVariable(t *testing.T) {
+	out, stderr, code := exec(t, "{{ .Update }}", false, "--stdin", "--update=x")
+	require.Equal(t, app.ExitStatus(0), code, stderr)
+	assert.Equal(t, "x\n", out)
+}
@@ -6,3 +6,4 @@ renderizer "${name}.html.tmpl" --items=apple --items=banana --items=cherry --foo
 renderizer "${name}.html.tmpl" --items=apple --items=banana --items=cherry --foo=true --settings=".${name}.yaml"
 renderizer "${name}.html.tmpl" --settings ".${name}.yaml"
 renderizer "${name}.html.tmpl" --items=apple --items=banana --items=cherry --foo=true --settings ".${name}.yaml"
+renderizer "${name}.html.tmpl" --html -C --items=apple --items="<script>alert(1)</script>"
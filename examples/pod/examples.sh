@@ -2,3 +2,4 @@
 name=$(basename "${PWD}")
 renderizer --testing
 renderizer "${name}.yaml.tmpl" --testing
+renderizer "${name}.yaml.tmpl" --testing '--containers[0].name=one' '--containers[1].name=two'
@@ -16,6 +16,10 @@ const (
 	name     = "analyze"
 	usage    = "infer the input data model a template requires"
 	argUsage = "[template-file]"
+
+	delimsFlag   = "delims"
+	htmlFlag     = "html"
+	htmlAutoFlag = "html-auto"
 )
 
 // Command returns the analyze subcommand.
@@ -25,11 +29,29 @@ func Command(rt app.Runtime) *cli.Command {
 		Usage:     usage,
 		ArgsUsage: argUsage,
 		Action:    action(rt),
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:    delimsFlag,
+				Usage:   `the template action delimiters, comma-separated (e.g. "[[,]]"; default: "{{,}}")`,
+				Sources: cli.EnvVars("RENDERIZER_DELIMS"),
+			},
+			&cli.BoolFlag{
+				Name:    htmlFlag,
+				Usage:   "parse the template with html/template",
+				Sources: cli.EnvVars("RENDERIZER_HTML"),
+			},
+			&cli.BoolFlag{
+				Name:    htmlAutoFlag,
+				Usage:   `parse the template with html/template when it is named "*.html.tmpl"`,
+				Sources: cli.EnvVars("RENDERIZER_HTML_AUTO"),
+			},
+		},
 	}
 }
 
 // action reads the template named by the first argument (or stdin) and writes
-// its inferred data-model skeleton.
+// its inferred data-model skeleton, parsed with the --delims delimiters and,
+// under --html or --html-auto, as HTML.
 func action(rt app.Runtime) cli.ActionFunc {
 	return func(ctx context.Context, cmd *cli.Command) error {
 		file := firstArg(cmd.Args().Slice())
@@ -38,9 +60,12 @@ func action(rt app.Runtime) cli.ActionFunc {
 		}
 		logger := app.NewLogger(cmd.Root().ErrWriter, false, false)
 		result, err := domain.Run(ctx, &logger, domain.Config{
-			Template: domain.TemplateFile(file),
-			Source:   rt.Source,
-			ReadFile: domain.ReadFileFunc(rt.ReadFile),
+			Template:        domain.TemplateFile(file),
+			Delims:          domain.TemplateDelims(cmd.String(delimsFlag)),
+			HTMLEnabled:     domain.HTMLEnabled(cmd.Bool(htmlFlag)),
+			HTMLAutoEnabled: domain.HTMLAutoEnabled(cmd.Bool(htmlAutoFlag)),
+			Source:          rt.Source,
+			ReadFile:        domain.ReadFileFunc(rt.ReadFile),
 		})
 		return app.Write(cmd.Root().Writer, result.Output, err)
 	}
@@ -6,6 +6,7 @@ package render
 
 import (
 	"context"
+	"time"
 
 	"github.com/urfave/cli/v3"
 
@@ -34,16 +35,47 @@ func Command(rt app.Runtime) *cli.Command {
 				app.Debugging(cfg.DebuggingEnabled),
 			)
 			result, err := domain.Run(ctx, &logger, configured(cfg, rt, cmd))
-			return app.Write(cmd.Root().Writer, result.Output, err)
+			return app.Write(cmd.Root().Writer, result.Output(), err)
 		},
 		Flags: []cli.Flag{
 			&cli.StringSliceFlag{
 				Name:        "settings",
 				Aliases:     []string{"S", "s"},
-				Usage:       `load settings from the provided YAMLs (default: ".<name>.yaml")`,
+				Usage:       `load settings from the provided YAMLs (default: discover ".renderizer.yaml" and ".<name>.yaml" up to the repository root)`,
 				Sources:     cli.EnvVars("RENDERIZER"),
 				Destination: (*[]string)(&cfg.Settings),
 			},
+			&cli.StringSliceFlag{
+				Name:        "lib",
+				Usage:       `parse the partials in these directories, globs, or files alongside every template, for "template" and "block" to use (adds to "lib" in settings)`,
+				Sources:     cli.EnvVars("RENDERIZER_LIB"),
+				Destination: (*[]string)(&cfg.Library),
+			},
+			&cli.StringSliceFlag{
+				Name:        "in-place",
+				Usage:       `render the regions of these files between "renderizer:begin TEMPLATE" and "renderizer:end" marker lines in place, instead of rendering templates`,
+				Sources:     cli.EnvVars("RENDERIZER_IN_PLACE"),
+				Destination: (*[]string)(&cfg.InPlace),
+			},
+			&cli.BoolFlag{
+				Name:        "check",
+				Usage:       "with --in-place, fail when a region is out of date instead of rewriting it",
+				Sources:     cli.EnvVars("RENDERIZER_CHECK"),
+				Destination: (*bool)(&cfg.CheckEnabled),
+			},
+			&cli.StringFlag{
+				Name:        "profile",
+				Aliases:     []string{"P"},
+				Usage:       `overlay ".<name>.<profile>.yaml" (then ".<name>.local.yaml") onto the settings`,
+				Sources:     cli.EnvVars("RENDERIZER_PROFILE"),
+				Destination: (*string)(&cfg.Profile),
+			},
+			&cli.BoolFlag{
+				Name:        "template-settings",
+				Usage:       "render settings files as templates against the variables and environment before parsing them",
+				Sources:     cli.EnvVars("RENDERIZER_TEMPLATE_SETTINGS"),
+				Destination: (*bool)(&cfg.TemplateSettingsEnabled),
+			},
 			&cli.StringFlag{
 				Name:        "missing",
 				Aliases:     []string{"M", "m"},
@@ -52,6 +84,31 @@ func Command(rt app.Runtime) *cli.Command {
 				Sources:     cli.EnvVars("RENDERIZER_MISSINGKEY"),
 				Destination: (*string)(&cfg.MissingKey),
 			},
+			&cli.StringFlag{
+				Name:        "delims",
+				Usage:       `the template action delimiters, comma-separated (e.g. "[[,]]"; default: "{{,}}")`,
+				Sources:     cli.EnvVars("RENDERIZER_DELIMS"),
+				Destination: (*string)(&cfg.Delims),
+			},
+			&cli.BoolFlag{
+				Name:        "html",
+				Usage:       "parse templates with html/template, escaping every value for the HTML context it lands in",
+				Sources:     cli.EnvVars("RENDERIZER_HTML"),
+				Destination: (*bool)(&cfg.HTMLEnabled),
+			},
+			&cli.BoolFlag{
+				Name:        "html-auto",
+				Usage:       `parse templates named "*.html.tmpl" with html/template`,
+				Sources:     cli.EnvVars("RENDERIZER_HTML_AUTO"),
+				Destination: (*bool)(&cfg.HTMLAutoEnabled),
+			},
+			&cli.StringFlag{
+				Name:        "merge",
+				Usage:       "how settings files and repeated variables combine (append|replace|deep-override|unique-append)",
+				Value:       "append",
+				Sources:     cli.EnvVars("RENDERIZER_MERGE"),
+				Destination: (*string)(&cfg.Merge),
+			},
 			&cli.StringFlag{
 				Name:        "environment",
 				Aliases:     []string{"env", "E", "e"},
@@ -60,6 +117,66 @@ func Command(rt app.Runtime) *cli.Command {
 				Sources:     cli.EnvVars("RENDERIZER_ENVIRONMENT"),
 				Destination: (*string)(&cfg.Environment),
 			},
+			&cli.StringSliceFlag{
+				Name:        "env-allow",
+				Usage:       "expose only the environment variables matching these globs to templates",
+				Sources:     cli.EnvVars("RENDERIZER_ENV_ALLOW"),
+				Destination: (*[]string)(&cfg.EnvironmentAllow),
+			},
+			&cli.StringSliceFlag{
+				Name:        "env-deny",
+				Usage:       "hide the environment variables matching these globs from templates, even if allowed",
+				Sources:     cli.EnvVars("RENDERIZER_ENV_DENY"),
+				Destination: (*[]string)(&cfg.EnvironmentDeny),
+			},
+			&cli.StringFlag{
+				Name:        "env-prefix",
+				Usage:       `read environment variables with this prefix as variables, nesting at each "__" (e.g. RNDR_DB__HOST)`,
+				Sources:     cli.EnvVars("RENDERIZER_ENV_PREFIX"),
+				Destination: (*string)(&cfg.EnvironmentPrefix),
+			},
+			&cli.BoolFlag{
+				Name:        "no-infer",
+				Usage:       "keep untyped variable and settings values as strings instead of guessing their types",
+				Sources:     cli.EnvVars("RENDERIZER_NO_INFER"),
+				Destination: (*bool)(&cfg.InferenceDisabled),
+			},
+			&cli.BoolFlag{
+				Name:        "sandbox",
+				Usage:       "allow templates only the functions without side effects, hide the environment map from them, and let them name no output file",
+				Sources:     cli.EnvVars("RENDERIZER_SANDBOX"),
+				Destination: (*bool)(&cfg.SandboxEnabled),
+			},
+			&cli.StringSliceFlag{
+				Name:        "allow-func",
+				Usage:       "allow these template functions into the sandbox",
+				Sources:     cli.EnvVars("RENDERIZER_ALLOW_FUNC"),
+				Destination: (*[]string)(&cfg.AllowedFuncs),
+			},
+			&cli.StringSliceFlag{
+				Name:        "deny-func",
+				Usage:       "remove these template functions, sandboxed or not",
+				Sources:     cli.EnvVars("RENDERIZER_DENY_FUNC"),
+				Destination: (*[]string)(&cfg.DeniedFuncs),
+			},
+			&cli.DurationFlag{
+				Name:        "timeout",
+				Usage:       "fail a template that takes longer than this to render (e.g. 5s; default: no limit)",
+				Sources:     cli.EnvVars("RENDERIZER_TIMEOUT"),
+				Destination: (*time.Duration)(&cfg.Timeout),
+			},
+			&cli.Int64Flag{
+				Name:        "max-output",
+				Usage:       "fail a template that renders more than this many bytes (default: no limit)",
+				Sources:     cli.EnvVars("RENDERIZER_MAX_OUTPUT"),
+				Destination: (*int64)(&cfg.MaxOutput),
+			},
+			&cli.Int64Flag{
+				Name:        "max-iterations",
+				Usage:       "fail a template that ranges over, or builds with until and untilStep, more than this many elements in all; seq and repeat are not counted (default: no limit)",
+				Sources:     cli.EnvVars("RENDERIZER_MAX_ITERATIONS"),
+				Destination: (*int64)(&cfg.MaxIterations),
+			},
 			&cli.BoolFlag{
 				Name:        "stdin",
 				Aliases:     []string{"c"},
@@ -74,6 +191,49 @@ func Command(rt app.Runtime) *cli.Command {
 				Sources:     cli.EnvVars("RENDERIZER_TESTING"),
 				Destination: (*bool)(&cfg.TestingEnabled),
 			},
+			&cli.Uint64Flag{
+				Name:        "seed",
+				Usage:       "with --testing, seed the sequence every random function draws from",
+				Sources:     cli.EnvVars("RENDERIZER_SEED"),
+				Destination: (*uint64)(&cfg.Seed),
+			},
+			&cli.TimestampFlag{
+				Name:        "now",
+				Usage:       "with --testing, fix the clock at this RFC 3339 time (e.g. 2024-01-02T03:04:05Z)",
+				Sources:     cli.EnvVars("RENDERIZER_NOW"),
+				Config:      cli.TimestampConfig{Layouts: []string{time.RFC3339}},
+				Destination: (*time.Time)(&cfg.Now),
+			},
+			&cli.BoolFlag{
+				Name:        "keep-going",
+				Usage:       "render every template even after one fails, then report all the failures",
+				Sources:     cli.EnvVars("RENDERIZER_KEEP_GOING"),
+				Destination: (*bool)(&cfg.KeepGoingEnabled),
+			},
+			&cli.BoolFlag{
+				Name:        "validate-output",
+				Usage:       "fail when an output is not valid YAML, JSON, XML or Go, as its name says it is",
+				Sources:     cli.EnvVars("RENDERIZER_VALIDATE_OUTPUT"),
+				Destination: (*bool)(&cfg.ValidateOutputEnabled),
+			},
+			&cli.BoolFlag{
+				Name:        "format-output",
+				Usage:       "validate each output and rewrite it canonically: JSON indented, Go gofmt-ed",
+				Sources:     cli.EnvVars("RENDERIZER_FORMAT_OUTPUT"),
+				Destination: (*bool)(&cfg.FormatOutputEnabled),
+			},
+			&cli.BoolFlag{
+				Name:        "explain",
+				Usage:       "print the merged context with the origin of every value instead of rendering",
+				Sources:     cli.EnvVars("RENDERIZER_EXPLAIN"),
+				Destination: (*bool)(&cfg.ExplainEnabled),
+			},
+			&cli.StringFlag{
+				Name:    app.ErrorFormatFlag,
+				Usage:   "how a failure is reported on stderr (text|json)",
+				Value:   string(app.ErrorFormatText),
+				Sources: cli.EnvVars("RENDERIZER_ERROR_FORMAT"),
+			},
 			&cli.BoolFlag{
 				Name:        "debugging",
 				Aliases:     []string{"debug", "D"},
@@ -102,6 +262,9 @@ func configured(cfg domain.Config, rt app.Runtime, cmd *cli.Command) domain.Conf
 	cfg.TimeFormat = domain.TimeFormat(rt.TimeFormat)
 	cfg.Source = rt.Source
 	cfg.ReadFile = domain.ReadFileFunc(rt.ReadFile)
+	cfg.WriteFile = domain.WriteFileFunc(rt.WriteFile)
+	cfg.ReadDir = domain.ReadDirFunc(rt.ReadDir)
+	cfg.Glob = domain.GlobFunc(rt.Glob)
 	cfg.Exists = domain.ExistsFunc(rt.Exists)
 	cfg.Getwd = domain.GetwdFunc(rt.Getwd)
 	cfg.Environ = domain.EnvironFunc(rt.Environ)
@@ -151,3 +151,11 @@ func runOnce(t *testing.T, cmd *cli.Command, args ...string) string {
 	require.NoError(t, cmd.Run(context.Background(), append([]string{"renderizer"}, args...)))
 	return stdout.String()
 }
+
+func TestRenderExplain(t *testing.T) {
+	rt := baseRuntime("")
+	rt.Assignments = []string{"--name=Bob"}
+	out, err := exec(t, rt, "--explain")
+	require.NoError(t, err)
+	assert.Contains(t, out, `Name = "Bob"  # from argument #0 (--name=Bob)`)
+}
@@ -0,0 +1,163 @@
+// Package test is the app-tier definition of the `test` subcommand, which
+// renders the golden-file test cases under a directory and compares what they
+// render with what they expect.
+package test
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"strings"
+
+	"github.com/urfave/cli/v3"
+
+	"github.com/gomatic/renderizer/internal/app"
+	domain "github.com/gomatic/renderizer/internal/domain/test"
+	"github.com/gomatic/renderizer/internal/golden"
+	"github.com/gomatic/renderizer/internal/variables"
+)
+
+const (
+	name     = "test"
+	usage    = "render the golden-file test cases under a directory and compare them with their expect.txt"
+	argUsage = "[dir]"
+
+	updateFlag  = "update"
+	testingFlag = "--testing"
+)
+
+// RootFunc returns the root render command for a runtime. The composition root
+// passes the render command's constructor, so a case renders exactly as the
+// command line would render it.
+type RootFunc func(rt app.Runtime) *cli.Command
+
+// Command returns the test subcommand, rendering each invocation with the
+// command root returns.
+func Command(rt app.Runtime, root RootFunc) *cli.Command {
+	return &cli.Command{
+		Name:      name,
+		Usage:     usage,
+		ArgsUsage: argUsage,
+		Action:    action(rt, root),
+		Flags: []cli.Flag{
+			&cli.BoolFlag{
+				Name:    updateFlag,
+				Usage:   "rewrite the expect.txt of each case that renders differently instead of failing it",
+				Sources: cli.EnvVars("RENDERIZER_UPDATE"),
+			},
+		},
+	}
+}
+
+// action tests the cases under the directory named by the first argument, or
+// the working directory, and writes the report.
+func action(rt app.Runtime, root RootFunc) cli.ActionFunc {
+	return func(ctx context.Context, cmd *cli.Command) error {
+		dir, err := suiteDir(rt, cmd.Args().First())
+		if err != nil {
+			return err
+		}
+		logger := app.NewLogger(cmd.Root().ErrWriter, false, false)
+		result, err := domain.Run(ctx, &logger, domain.Config{
+			Render:        render(rt, root),
+			ReadFile:      domain.ReadFileFunc(rt.ReadFile),
+			WriteFile:     domain.WriteFileFunc(rt.WriteFile),
+			ReadDir:       domain.ReadDirFunc(rt.ReadDir),
+			Dir:           domain.SuiteDir(dir),
+			UpdateEnabled: domain.UpdateEnabled(cmd.Bool(updateFlag)),
+		})
+		return app.Write(cmd.Root().Writer, result.Output(), err)
+	}
+}
+
+// suiteDir returns dir, or the working directory when empty, as an absolute
+// path, so a case's directory always has a name.
+func suiteDir(rt app.Runtime, dir string) (string, error) {
+	if filepath.IsAbs(dir) {
+		return dir, nil
+	}
+	wd, err := rt.Getwd()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(wd, dir), nil
+}
+
+// render returns the seam rendering one invocation of a case: as the command
+// root returns renders its arguments when run in the case's directory, in
+// testing mode, with the case's environment alone. It captures what the
+// command writes — each file it would write, after a file marker naming it,
+// then its output — rather than writing any file.
+func render(rt app.Runtime, root RootFunc) domain.RenderFunc {
+	return func(ctx context.Context, invocation golden.Invocation) ([]byte, error) {
+		var out bytes.Buffer
+		tokens := variables.Tokenize(invocation.Args, app.LongFlags(root(app.Runtime{})))
+		cased := rooted(rt, invocation, &out)
+		cased.Assignments = tokens.Assignments
+		command := root(cased)
+		command.Writer, command.ErrWriter = &out, io.Discard
+		free, cancel := detached(ctx)
+		defer cancel()
+		err := command.Run(free, append([]string{command.Name, testingFlag}, tokens.Args...))
+		return out.Bytes(), err
+	}
+}
+
+// detached returns a context cancelled with ctx but carrying none of its
+// values: urfave/cli makes a command it finds in the context of a command it
+// runs that command's parent, and a case's command must be a root of its own,
+// writing where the test command captures it.
+func detached(ctx context.Context) (context.Context, context.CancelFunc) {
+	free, cancel := context.WithCancelCause(context.Background())
+	stop := context.AfterFunc(ctx, func() { cancel(context.Cause(ctx)) })
+	return free, func() {
+		stop()
+		cancel(nil)
+	}
+}
+
+// rooted returns rt as seen from the directory of invocation, with its
+// environment, no input, and the files it writes captured in out.
+func rooted(rt app.Runtime, invocation golden.Invocation, out io.Writer) app.Runtime {
+	in := func(name string) string {
+		if filepath.IsAbs(name) {
+			return name
+		}
+		return filepath.Join(invocation.Dir, name)
+	}
+	return app.Runtime{
+		Source:   strings.NewReader(""),
+		ReadFile: func(name string) ([]byte, error) { return rt.ReadFile(in(name)) },
+		WriteFile: func(name string, data []byte) error {
+			_, err := fmt.Fprintf(out, "---# file: %s\n%s", name, data)
+			return err
+		},
+		ReadDir:           func(name string) ([]fs.DirEntry, error) { return rt.ReadDir(in(name)) },
+		Glob:              func(pattern string) ([]string, error) { return rt.Glob(in(pattern)) },
+		Exists:            func(name string) bool { return rt.Exists(in(name)) },
+		Getwd:             func() (string, error) { return invocation.Dir, nil },
+		Environ:           func() []string { return caseEnviron(rt, invocation) },
+		TimeFormat:        rt.TimeFormat,
+		CapitalizeEnabled: rt.CapitalizeEnabled,
+	}
+}
+
+// versionVariable is the variable main exposes renderizer's version to
+// templates in.
+const versionVariable = "RENDERIZER_VERSION"
+
+// caseEnviron returns the environment of invocation: PWD naming its directory,
+// as a shell's does, the version main exposes in rt's, and the variables its
+// script exports, in that order, so an export can override either.
+func caseEnviron(rt app.Runtime, invocation golden.Invocation) []string {
+	environ := []string{"PWD=" + invocation.Dir}
+	for _, entry := range rt.Environ() {
+		if strings.HasPrefix(entry, versionVariable+"=") {
+			environ = append(environ, entry)
+		}
+	}
+	return append(environ, invocation.Environ...)
+}
@@ -0,0 +1,95 @@
+package test_test
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gomatic/renderizer/internal/app"
+	"github.com/gomatic/renderizer/internal/app/commands/render"
+	"github.com/gomatic/renderizer/internal/app/commands/test"
+	"github.com/gomatic/renderizer/internal/constants"
+)
+
+func osRuntime(dir string) app.Runtime {
+	return app.Runtime{
+		Source:    strings.NewReader(""),
+		ReadFile:  os.ReadFile,
+		WriteFile: func(name string, data []byte) error { return os.WriteFile(name, data, 0o644) },
+		ReadDir:   os.ReadDir,
+		Glob:      filepath.Glob,
+		Exists: func(name string) bool {
+			_, err := os.Stat(name)
+			return err == nil
+		},
+		Getwd:             func() (string, error) { return dir, nil },
+		Environ:           func() []string { return []string{"HOME=/home", "RENDERIZER_VERSION=1.2.3"} },
+		CapitalizeEnabled: true,
+		TimeFormat:        "20060102T150405",
+	}
+}
+
+func write(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+	}
+}
+
+func exec(t *testing.T, dir string, args ...string) (string, error) {
+	t.Helper()
+	cmd := test.Command(osRuntime(dir), render.Command)
+	var stdout, stderr bytes.Buffer
+	cmd.Writer = &stdout
+	cmd.ErrWriter = &stderr
+	err := cmd.Run(context.Background(), append([]string{"test"}, args...))
+	return stdout.String(), err
+}
+
+// TestCommand pins that each case renders in its own directory, in testing
+// mode, with its script's arguments and exports and renderizer's version
+// alone, capturing the files it writes, and that --update rewrites the
+// expectations that differ.
+func TestCommand(t *testing.T) {
+	t.Parallel()
+	dir := t.TempDir()
+	write(t, dir, map[string]string{
+		"greet/greet.txt.tmpl": "{{ .greeting }} {{ .Name }} {{ now.Year }} {{ environment \"HOME\" }}{{ environment \"WHO\" }} {{ .env.RENDERIZER_VERSION }}\n",
+		"greet/.greet.yaml":    "greeting: hello\n",
+		"greet/examples.sh":    "export WHO=world\nrenderizer --name=Ann\nrenderizer \"${name}.txt.tmpl\" --name=Bob\n",
+		"greet/expect.txt":     "hello Ann 2006 world 1.2.3\n\nhello Bob 2006 world 1.2.3\n\n",
+		"split/split.txt.tmpl": "---# file: a.txt\na\n",
+		"split/examples.sh":    "renderizer split.txt.tmpl\n",
+		"split/expect.txt":     "stale\n",
+	})
+
+	out, err := exec(t, dir, "suite")
+	require.ErrorIs(t, err, os.ErrNotExist)
+	assert.Empty(t, out)
+
+	out, err = exec(t, dir)
+	require.ErrorIs(t, err, constants.ErrTestCaseFailed)
+	assert.Contains(t, out, "ok       greet\n")
+	assert.Contains(t, out, "FAIL     split\n")
+	assert.Contains(t, out, "\t-stale\n")
+
+	out, err = exec(t, dir, "--update", dir)
+	require.NoError(t, err)
+	assert.Contains(t, out, "updated  split\n")
+	updated, err := os.ReadFile(filepath.Join(dir, "split", "expect.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "---# file: a.txt\na\n", string(updated))
+	assert.NoFileExists(t, filepath.Join(dir, "split", "a.txt"))
+
+	out, err = exec(t, dir, "split")
+	require.NoError(t, err)
+	assert.Equal(t, "ok       .\n1 passed, 0 failed, 0 updated\n", out)
+}
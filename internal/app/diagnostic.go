@@ -0,0 +1,114 @@
+package app
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/gomatic/renderizer/internal/constants"
+)
+
+// diagnose writes err the way a compiler reports one, and the failures a
+// keep-going run collected each in turn, followed by a table summarizing them.
+func diagnose(w io.Writer, err error) error {
+	collected := failures(err)
+	if collected == nil {
+		return diagnostic(w, err)
+	}
+	for _, failure := range collected {
+		if err := diagnostic(w, failure); err != nil {
+			return err
+		}
+	}
+	return summarize(w, collected)
+}
+
+// diagnostic writes one failure the way a compiler reports it. A located
+// template failure reads as its position, stage, and reason, then the source
+// line with a caret under the failing action, then any suggestion:
+//
+//	page.tmpl:3:9: execute error: map has no entry for key "Nmae"
+//	 3 | Hello, {{ .Nmae }}!
+//	   |           ^
+//	did you mean `Name`?
+//
+// Any other error reads as its message.
+func diagnostic(w io.Writer, err error) error {
+	var located *constants.TemplateError
+	if !errors.As(err, &located) || located.Line == 0 {
+		_, writeErr := fmt.Fprintf(w, "renderizer: %v\n", err)
+		return writeErr
+	}
+	var text strings.Builder
+	fmt.Fprintf(&text, "%s: %s error: %s\n", where(located), located.Stage(), located.Reason)
+	if located.Source != "" {
+		snippet(&text, located)
+	}
+	if located.Suggestion != "" {
+		fmt.Fprintf(&text, "did you mean `%s`?\n", located.Suggestion)
+	}
+	_, writeErr := io.WriteString(w, text.String())
+	return writeErr
+}
+
+// where renders a failure's position as "template:line[:column]".
+func where(located *constants.TemplateError) string {
+	if located.Column == 0 {
+		return fmt.Sprintf("%s:%d", located.Template, located.Line)
+	}
+	return fmt.Sprintf("%s:%d:%d", located.Template, located.Line, located.Column)
+}
+
+// snippet writes the failing source line after a gutter holding its number
+// and, when the column is known, a caret beneath the column.
+func snippet(text *strings.Builder, located *constants.TemplateError) {
+	number := strconv.Itoa(located.Line)
+	fmt.Fprintf(text, " %s | %s\n", number, located.Source)
+	if located.Column > 0 && located.Column <= len(located.Source)+1 {
+		gutter := strings.Repeat(" ", len(number))
+		fmt.Fprintf(text, " %s | %s^\n", gutter, blank(located.Source[:located.Column-1]))
+	}
+}
+
+// blank returns prefix with every character but a tab turned to a space, so a
+// caret after it lines up under the column however tabs are displayed.
+func blank(prefix string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '\t' {
+			return r
+		}
+		return ' '
+	}, prefix)
+}
+
+// summarize writes a table of the collected failures: each template with the
+// stage it failed in and the exit status that stage maps to.
+func summarize(w io.Writer, collected []*constants.TemplateError) error {
+	table := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(table, "\n%d of the templates failed:\nTEMPLATE\tSTAGE\tSTATUS\n", len(collected))
+	for _, failure := range collected {
+		fmt.Fprintf(table, "%s\t%s\t%d\n", failure.Template, failure.Stage(), ExitCode(failure))
+	}
+	return table.Flush()
+}
+
+// failures returns the failures a keep-going run collected with errors.Join,
+// each attributed to its template, or nil when err is a single failure.
+func failures(err error) []*constants.TemplateError {
+	var joined interface{ Unwrap() []error }
+	if !errors.As(err, &joined) {
+		return nil
+	}
+	collected := make([]*constants.TemplateError, 0, len(joined.Unwrap()))
+	for _, wrapped := range joined.Unwrap() {
+		var located *constants.TemplateError
+		if !errors.As(wrapped, &located) {
+			return nil
+		}
+		collected = append(collected, located)
+	}
+	return collected
+}
@@ -1,14 +1,12 @@
 package app
 
-import (
-	"errors"
-
-	"github.com/gomatic/renderizer/internal/constants"
-)
+import "github.com/gomatic/renderizer/internal/constants"
 
 // Exit codes preserve the historical renderizer semantics: distinct codes per
 // failure stage so scripts can distinguish a read failure from a template
-// parse or execute failure.
+// parse or execute failure. The limits a render can run past follow the
+// historical codes, so a script can tell a runaway template from a broken one.
+// An output that renders but is malformed for its type fails past them all.
 type ExitStatus int
 
 const (
@@ -18,24 +16,39 @@ const (
 	exitParse   ExitStatus = 4
 	exitExecute ExitStatus = 8
 	exitPanic   ExitStatus = 15
+
+	exitTimeout        ExitStatus = 16
+	exitOutputLimit    ExitStatus = 17
+	exitIterationLimit ExitStatus = 18
+
+	exitValidate ExitStatus = 19
 )
 
-// ExitCode maps a Run error to a process exit code. A nil error is success; a
-// recognized sentinel maps to its historical code; anything else is a generic
-// failure.
+// ExitCode maps a Run error to a process exit code. A nil error is success; an
+// error failing in a known stage maps to that stage's historical code; anything
+// else is a generic failure.
 func ExitCode(err error) ExitStatus {
-	switch {
-	case err == nil:
+	if err == nil {
 		return exitSuccess
-	case errors.Is(err, constants.ErrParseTemplate):
+	}
+	switch constants.StageOf(err) {
+	case constants.StageParse:
 		return exitParse
-	case errors.Is(err, constants.ErrExecuteTemplate):
+	case constants.StageExecute:
 		return exitExecute
-	case errors.Is(err, constants.ErrReadTemplate):
+	case constants.StageRead:
 		return exitRead
-	case errors.Is(err, constants.ErrRenderPanic):
+	case constants.StagePanic:
 		return exitPanic
-	default:
-		return exitGeneric
+	case constants.StageTimeout:
+		return exitTimeout
+	case constants.StageOutputLimit:
+		return exitOutputLimit
+	case constants.StageIterationLimit:
+		return exitIterationLimit
+	case constants.StageValidate:
+		return exitValidate
+	case constants.StageGeneric:
 	}
+	return exitGeneric
 }
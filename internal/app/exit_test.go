@@ -30,9 +30,18 @@ func TestExitCodeMapsEachSentinelToItsHistoricalStatus(t *testing.T) {
 		want    int
 	}{
 		{name: "read", wantErr: constants.ErrReadTemplate, want: 2},
+		{name: "read library", wantErr: constants.ErrReadLibrary, want: 2},
 		{name: "parse", wantErr: constants.ErrParseTemplate, want: 4},
+		{name: "escape", wantErr: constants.ErrEscapeTemplate, want: 4},
+		{name: "front matter", wantErr: constants.ErrParseFrontMatter, want: 4},
+		{name: "markers", wantErr: constants.ErrParseMarkers, want: 4},
 		{name: "execute", wantErr: constants.ErrExecuteTemplate, want: 8},
+		{name: "required key", wantErr: constants.ErrRequiredKey, want: 8},
 		{name: "panic", wantErr: constants.ErrRenderPanic, want: 15},
+		{name: "timeout", wantErr: constants.ErrRenderTimeout, want: 16},
+		{name: "output limit", wantErr: constants.ErrOutputLimit, want: 17},
+		{name: "iteration limit", wantErr: constants.ErrIterationLimit, want: 18},
+		{name: "validate", wantErr: constants.ErrValidateOutput, want: 19},
 	} {
 		t.Run(tc.name, func(t *testing.T) {
 			t.Parallel()
@@ -60,6 +69,22 @@ func TestExitCodeDistinguishesSuccessFromAnUnrecognizedFailure(t *testing.T) {
 	assert.Equal(t, app.ExitStatus(1), app.ExitCode(errors.New("something else")))
 }
 
+// TestExitCodeReportsTheMostSevereOfJoinedFailures pins the keep-going
+// contract: failures collected with errors.Join exit with the highest status
+// among them, whatever order they occurred in.
+func TestExitCodeReportsTheMostSevereOfJoinedFailures(t *testing.T) {
+	t.Parallel()
+	cause := errors.New("boom")
+	read := errs(t, constants.ErrReadTemplate, cause)
+	parse := errs(t, constants.ErrParseTemplate, cause)
+	execute := errs(t, constants.ErrExecuteTemplate, cause)
+
+	assert.Equal(t, app.ExitStatus(8), app.ExitCode(errors.Join(parse, execute, read)))
+	assert.Equal(t, app.ExitStatus(8), app.ExitCode(errors.Join(execute, parse)))
+	assert.Equal(t, app.ExitStatus(4), app.ExitCode(errors.Join(errors.New("other"), parse)))
+	assert.Equal(t, app.ExitStatus(1), app.ExitCode(errors.Join(errors.New("other"))))
+}
+
 // TestExitCodeSentinelsAreMutuallyDistinct guards the mapping's premise. The
 // switch returns on the first matching arm, so two sentinels that matched each
 // other would make one of them unreachable and its code unreportable — a
@@ -70,8 +95,13 @@ func TestExitCodeSentinelsAreMutuallyDistinct(t *testing.T) {
 	sentinels := []error{
 		constants.ErrReadTemplate,
 		constants.ErrParseTemplate,
+		constants.ErrEscapeTemplate,
 		constants.ErrExecuteTemplate,
 		constants.ErrRenderPanic,
+		constants.ErrRenderTimeout,
+		constants.ErrOutputLimit,
+		constants.ErrIterationLimit,
+		constants.ErrValidateOutput,
 	}
 
 	for i, a := range sentinels {
@@ -0,0 +1,37 @@
+package app
+
+import (
+	"github.com/urfave/cli/v3"
+
+	"github.com/gomatic/renderizer/internal/variables"
+)
+
+// LongFlags returns the long flag names of cmd and of its subcommands, with
+// the help flag, and the version flag of a command with a version, that
+// urfave/cli adds to them: what variables.Tokenize must pass through.
+func LongFlags(cmd *cli.Command) variables.Flags {
+	flags := variables.Flags{Long: long(cmd.Flags), Subcommands: map[string]variables.Flags{}}
+	flags.Long = append(flags.Long, long([]cli.Flag{cli.HelpFlag})...)
+	if cmd.Version != "" {
+		flags.Long = append(flags.Long, long([]cli.Flag{cli.VersionFlag})...)
+	}
+	for _, subcommand := range cmd.Commands {
+		for _, name := range subcommand.Names() {
+			flags.Subcommands[name] = LongFlags(subcommand)
+		}
+	}
+	return flags
+}
+
+// long returns the names of flags longer than a letter.
+func long(flags []cli.Flag) []string {
+	var names []string
+	for _, flag := range flags {
+		for _, name := range flag.Names() {
+			if len(name) > 1 {
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
@@ -0,0 +1,133 @@
+package app
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/gomatic/renderizer/internal/constants"
+)
+
+// ErrorFormat selects how a failed run is reported on stderr.
+type ErrorFormat string
+
+// ErrorFormatFlag names the root flag selecting the error format, which the
+// composition root reads once the command has run.
+const ErrorFormatFlag = "error-format"
+
+// The error formats.
+const (
+	// ErrorFormatText writes a compiler-style diagnostic for a person to read.
+	ErrorFormatText ErrorFormat = "text"
+	// ErrorFormatJSON writes one JSON object describing the failure, for an
+	// editor or script to read without parsing messages.
+	ErrorFormatJSON ErrorFormat = "json"
+)
+
+// errorReport is the JSON form of a failure. Stage and Status are derived
+// from the error exactly as ExitCode derives the exit status; the location
+// fields are present when the error is a located template failure, and the
+// failures a keep-going run collected are each reported under Failures.
+type errorReport struct {
+	Stage      constants.Stage `json:"stage"`
+	Message    string          `json:"message"`
+	Template   string          `json:"template,omitempty"`
+	Source     string          `json:"source,omitempty"`
+	Path       string          `json:"path,omitempty"`
+	Reason     string          `json:"reason,omitempty"`
+	Suggestion string          `json:"suggestion,omitempty"`
+	Causes     []string        `json:"causes"`
+	Failures   []errorReport   `json:"failures,omitempty"`
+	Status     ExitStatus      `json:"status"`
+	Line       int             `json:"line,omitempty"`
+	Column     int             `json:"column,omitempty"`
+}
+
+// Report writes err to w in format; a nil err writes nothing. An unknown
+// format reports as text.
+func Report(w io.Writer, format ErrorFormat, err error) error {
+	if err == nil {
+		return nil
+	}
+	switch format {
+	case ErrorFormatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetEscapeHTML(false)
+		return encoder.Encode(report(err))
+	case ErrorFormatText:
+	}
+	return diagnose(w, err)
+}
+
+// report describes err for its JSON form.
+func report(err error) errorReport {
+	described := errorReport{
+		Stage:   constants.StageOf(err),
+		Message: err.Error(),
+		Causes:  causes(err),
+		Status:  ExitCode(err),
+	}
+	collected := failures(err)
+	for _, failure := range collected {
+		described.Failures = append(described.Failures, report(failure))
+	}
+	var located *constants.TemplateError
+	if collected == nil && errors.As(err, &located) {
+		described.Template, described.Source, described.Path = located.Template, located.Source, located.Path
+		described.Reason, described.Suggestion = located.Reason, located.Suggestion
+		described.Line, described.Column = located.Line, located.Column
+	}
+	return described
+}
+
+// maxCauses bounds the errors causes walks, so a runaway chain reports its
+// outermost causes rather than all of them.
+const maxCauses = 32
+
+// causes lists the messages of the errors err wraps, depth first, each by its
+// own words: an ordinary wrapper's message less the message it wraps, which
+// follows it. A joined error, whose own message is only its parts', and a
+// wrapper adding nothing are left out, and the walk stops after maxCauses
+// errors, so the list grows with a chain's depth, not its square.
+func causes(err error) []string {
+	chain := []string{}
+	pending := unwrapped(err)
+	for visited := 0; len(pending) > 0 && visited < maxCauses; visited++ {
+		wrapped := pending[0]
+		inner := unwrapped(wrapped)
+		pending = slices.Concat(inner, pending[1:])
+		if message := ownMessage(wrapped, inner); message != "" {
+			chain = append(chain, message)
+		}
+	}
+	return chain
+}
+
+// ownMessage returns the words err's message adds to that of inner, the
+// errors it wraps: all of it when it wraps none, what precedes the one it
+// wraps when it wraps one, and nothing when it joins several.
+func ownMessage(err error, inner []error) string {
+	switch len(inner) {
+	case 0:
+		return err.Error()
+	case 1:
+		own := strings.TrimSuffix(err.Error(), inner[0].Error())
+		return strings.TrimRight(own, ": ")
+	}
+	return ""
+}
+
+// unwrapped returns the errors err directly wraps: the one of an ordinary
+// wrapper, or all of a joined one.
+func unwrapped(err error) []error {
+	if inner := errors.Unwrap(err); inner != nil {
+		return []error{inner}
+	}
+	var joined interface{ Unwrap() []error }
+	if errors.As(err, &joined) {
+		return joined.Unwrap()
+	}
+	return nil
+}
@@ -0,0 +1,174 @@
+package app_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gomatic/renderizer/internal/app"
+	"github.com/gomatic/renderizer/internal/constants"
+)
+
+// TestReportJSONDescribesALocatedFailure pins the fields an editor integration
+// reads. The stage and status must be the ones ExitCode derives, or the report
+// and the exit status would disagree about what failed.
+func TestReportJSONDescribesALocatedFailure(t *testing.T) {
+	t.Parallel()
+	cause := errors.New(`template: t:2:5: executing "t" at <.A>: map has no entry for key "A"`)
+	err := &constants.TemplateError{
+		Cause:    constants.ErrExecuteTemplate.With(cause),
+		Template: "t",
+		Source:   "x = {{ .A }}",
+		Path:     ".A",
+		Line:     2,
+		Column:   6,
+	}
+
+	var out bytes.Buffer
+	require.NoError(t, app.Report(&out, app.ErrorFormatJSON, err))
+
+	var got map[string]any
+	require.NoError(t, json.Unmarshal(out.Bytes(), &got))
+	assert.Equal(t, map[string]any{
+		"stage":    "execute",
+		"status":   float64(app.ExitCode(err)),
+		"message":  err.Error(),
+		"template": "t",
+		"source":   "x = {{ .A }}",
+		"path":     ".A",
+		"line":     float64(2),
+		"column":   float64(6),
+		"causes":   []any{"failed to execute template", cause.Error()},
+	}, got)
+}
+
+// TestReportJSONDescribesAnUnlocatedFailure covers an error that is not a
+// template failure: it still reports its stage and causes, without location.
+func TestReportJSONDescribesAnUnlocatedFailure(t *testing.T) {
+	t.Parallel()
+	err := constants.ErrReadTemplate.With(errors.New("boom"), "x.tmpl")
+
+	var out bytes.Buffer
+	require.NoError(t, app.Report(&out, app.ErrorFormatJSON, err))
+
+	var got map[string]any
+	require.NoError(t, json.Unmarshal(out.Bytes(), &got))
+	assert.Equal(t, "read", got["stage"])
+	assert.InDelta(t, 2, got["status"], 0)
+	assert.NotContains(t, got, "line")
+	assert.Contains(t, got["causes"], "boom")
+}
+
+// TestReportJSONCausesOfADeepChain pins that each cause is reported by its own
+// words, not repeating every message beneath it, and that a runaway chain
+// reports only its outermost causes, so the report stays small.
+func TestReportJSONCausesOfADeepChain(t *testing.T) {
+	t.Parallel()
+	err := errors.New("boom")
+	for depth := range 5000 {
+		err = fmt.Errorf("level %d: %w", depth, err)
+	}
+
+	var out bytes.Buffer
+	require.NoError(t, app.Report(&out, app.ErrorFormatJSON, err))
+
+	var got struct {
+		Causes []string `json:"causes"`
+	}
+	require.NoError(t, json.Unmarshal(out.Bytes(), &got))
+	require.Len(t, got.Causes, 32)
+	assert.Equal(t, "level 4998", got.Causes[0])
+	assert.Equal(t, "level 4967", got.Causes[31])
+	assert.Less(t, out.Len(), 2*len(err.Error())+4096, "causes add little to the message")
+
+	shallow := fmt.Errorf("outer: %w", fmt.Errorf("inner: %w", errors.New("boom")))
+	out.Reset()
+	require.NoError(t, app.Report(&out, app.ErrorFormatJSON, shallow))
+	require.NoError(t, json.Unmarshal(out.Bytes(), &got))
+	assert.Equal(t, []string{"inner", "boom"}, got.Causes)
+}
+
+// TestReportTextDiagnosesALocatedFailure pins the compiler-style layout: the
+// caret sits under the column, past tabs kept as tabs so it lines up however
+// they are displayed.
+func TestReportTextDiagnosesALocatedFailure(t *testing.T) {
+	t.Parallel()
+	err := &constants.TemplateError{
+		Cause:      constants.ErrExecuteTemplate.With(errors.New("boom")),
+		Template:   "t",
+		Source:     "\tx = {{ .Nmae }}",
+		Reason:     `map has no entry for key "Nmae"`,
+		Suggestion: "Name",
+		Line:       12,
+		Column:     10,
+	}
+
+	var out bytes.Buffer
+	require.NoError(t, app.Report(&out, app.ErrorFormatText, err))
+	assert.Equal(t, "t:12:10: execute error: map has no entry for key \"Nmae\"\n"+
+		" 12 | \tx = {{ .Nmae }}\n"+
+		"    | \t        ^\n"+
+		"did you mean `Name`?\n", out.String())
+}
+
+// TestReportTextWritesAnyOtherErrorAsItsMessage covers failures with no
+// template position, and an unknown format falling back to text.
+func TestReportTextWritesAnyOtherErrorAsItsMessage(t *testing.T) {
+	t.Parallel()
+	var out bytes.Buffer
+	require.NoError(t, app.Report(&out, "bogus", errors.New("boom")))
+	assert.Equal(t, "renderizer: boom\n", out.String())
+}
+
+// TestReportWritesNothingForSuccess keeps a successful run quiet.
+func TestReportWritesNothingForSuccess(t *testing.T) {
+	t.Parallel()
+	var out bytes.Buffer
+	require.NoError(t, app.Report(&out, app.ErrorFormatJSON, nil))
+	require.NoError(t, app.Report(&out, app.ErrorFormatText, nil))
+	assert.Empty(t, out.String())
+}
+
+// TestReportSummarizesJoinedFailures covers a keep-going run: each failure is
+// reported in turn, then tabulated with its stage and status; in JSON each is
+// reported under failures.
+func TestReportSummarizesJoinedFailures(t *testing.T) {
+	t.Parallel()
+	err := errors.Join(
+		&constants.TemplateError{Cause: constants.ErrParseTemplate.With(errors.New("bad")), Template: "a.tmpl"},
+		&constants.TemplateError{Cause: constants.ErrExecuteTemplate.With(errors.New("worse")), Template: "long.tmpl"},
+	)
+
+	var text bytes.Buffer
+	require.NoError(t, app.Report(&text, app.ErrorFormatText, err))
+	assert.Equal(t, "renderizer: failed to parse template: bad\n"+
+		"renderizer: failed to execute template: worse\n"+
+		"\n2 of the templates failed:\n"+
+		"TEMPLATE   STAGE    STATUS\n"+
+		"a.tmpl     parse    4\n"+
+		"long.tmpl  execute  8\n", text.String())
+
+	var out bytes.Buffer
+	require.NoError(t, app.Report(&out, app.ErrorFormatJSON, err))
+	var got struct {
+		Stage    string `json:"stage"`
+		Template string `json:"template"`
+		Failures []struct {
+			Template string `json:"template"`
+			Status   int    `json:"status"`
+		} `json:"failures"`
+		Status int `json:"status"`
+	}
+	require.NoError(t, json.Unmarshal(out.Bytes(), &got))
+	assert.Equal(t, "execute", got.Stage)
+	assert.Equal(t, 8, got.Status)
+	assert.Empty(t, got.Template, "the joined report describes no single template")
+	require.Len(t, got.Failures, 2)
+	assert.Equal(t, "a.tmpl", got.Failures[0].Template)
+	assert.Equal(t, 4, got.Failures[0].Status)
+}
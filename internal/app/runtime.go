@@ -1,6 +1,9 @@
 package app
 
-import "io"
+import (
+	"io"
+	"io/fs"
+)
 
 // PipedInput reports whether stdin arrives from a pipe rather than a terminal,
 // which turns on implicit stdin rendering.
@@ -13,6 +16,9 @@ type PipedInput bool
 type Runtime struct {
 	Source            io.Reader
 	ReadFile          func(name string) ([]byte, error)
+	WriteFile         func(name string, data []byte) error
+	ReadDir           func(name string) ([]fs.DirEntry, error)
+	Glob              func(pattern string) ([]string, error)
 	Exists            func(name string) bool
 	Getwd             func() (string, error)
 	Environ           func() []string
@@ -10,14 +10,36 @@ import errs "github.com/gomatic/go-error"
 
 // Keep these constants sorted alphabetically.
 const (
-	ErrExecuteTemplate errs.Const = "failed to execute template"
-	ErrMergeContext    errs.Const = "failed to merge context"
-	ErrMissingTemplate errs.Const = "missing template name"
-	ErrOpenTemplate    errs.Const = "failed to open template"
-	ErrParseSettings   errs.Const = "failed to parse settings file"
-	ErrParseTemplate   errs.Const = "failed to parse template"
-	ErrReadSettings    errs.Const = "failed to read settings file"
-	ErrReadTemplate    errs.Const = "failed to read template"
-	ErrRenderPanic     errs.Const = "template rendering panicked"
-	ErrWriteOutput     errs.Const = "failed to write output"
+	ErrEnvironmentPattern errs.Const = "invalid environment pattern"
+	ErrEscapeTemplate     errs.Const = "failed to escape template"
+	ErrExecuteTemplate    errs.Const = "failed to execute template"
+	ErrIterationLimit     errs.Const = "template exceeded its iteration limit"
+	ErrMergeContext       errs.Const = "failed to merge context"
+	ErrMissingTemplate    errs.Const = "missing template name"
+	ErrMissingTestCases   errs.Const = "no test cases found"
+	ErrNestingLimit       errs.Const = "include and tpl nested too deeply"
+	ErrOpenTemplate       errs.Const = "failed to open template"
+	ErrOutputLimit        errs.Const = "template exceeded its output limit"
+	ErrOutputPath         errs.Const = "output path leaves the working directory"
+	ErrParseDelims        errs.Const = "invalid template delimiters"
+	ErrParseFrontMatter   errs.Const = "failed to parse template front matter"
+	ErrParseMarkers       errs.Const = "invalid renderizer markers"
+	ErrParseScript        errs.Const = "failed to parse test script"
+	ErrParseSettings      errs.Const = "failed to parse settings file"
+	ErrParseTemplate      errs.Const = "failed to parse template"
+	ErrParseVariable      errs.Const = "failed to parse variable name"
+	ErrReadLibrary        errs.Const = "failed to read template library"
+	ErrReadSettings       errs.Const = "failed to read settings file"
+	ErrReadTemplate       errs.Const = "failed to read template"
+	ErrRegionOutput       errs.Const = "in-place region template names an output file"
+	ErrRemovedFunction    errs.Const = "template function not allowed"
+	ErrRenderPanic        errs.Const = "template rendering panicked"
+	ErrRenderTimeout      errs.Const = "template rendering timed out"
+	ErrRequiredKey        errs.Const = "template requires a missing key"
+	ErrSandboxedOutput    errs.Const = "sandboxed template cannot name an output file"
+	ErrStaleRegion        errs.Const = "generated region is out of date"
+	ErrTestCaseFailed     errs.Const = "test case failed"
+	ErrTypeVariable       errs.Const = "failed to type variable"
+	ErrValidateOutput     errs.Const = "rendered output is malformed"
+	ErrWriteOutput        errs.Const = "failed to write output"
 )
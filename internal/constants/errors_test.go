@@ -70,3 +70,18 @@ func TestErrorTextIsStable(t *testing.T) {
 	assert.Equal(t, "missing template name", constants.ErrMissingTemplate.Error())
 	assert.Equal(t, "failed to open template", constants.ErrOpenTemplate.Error())
 }
+
+// TestTemplateErrorIsTransparent pins that locating a failure changes neither
+// its message nor which sentinel it matches, so the stage — and with it the
+// exit status — is the stage of the wrapped cause.
+func TestTemplateErrorIsTransparent(t *testing.T) {
+	t.Parallel()
+	cause := constants.ErrParseTemplate.With(errors.New("boom"))
+	located := &constants.TemplateError{Cause: cause, Template: "t", Line: 1}
+
+	require.ErrorIs(t, located, constants.ErrParseTemplate)
+	assert.Equal(t, cause.Error(), located.Error())
+	assert.Equal(t, constants.StageParse, located.Stage())
+	assert.Equal(t, constants.StageParse, constants.StageOf(fmt.Errorf("wrapped: %w", located)))
+	assert.Equal(t, constants.StageGeneric, constants.StageOf(errors.New("other")))
+}
@@ -0,0 +1,90 @@
+package constants
+
+import "errors"
+
+// Stage names the step of a run an error failed in. It is derived from the
+// sentinel the error wraps, in one place, so the exit status and every error
+// report agree on it.
+type Stage string
+
+// The failure stages, one per distinct exit status.
+const (
+	StageGeneric Stage = "generic"
+	StageRead    Stage = "read"
+	StageParse   Stage = "parse"
+	StageExecute Stage = "execute"
+	StagePanic   Stage = "panic"
+	// The limits a render can run past.
+	StageTimeout        Stage = "timeout"
+	StageOutputLimit    Stage = "output-limit"
+	StageIterationLimit Stage = "iteration-limit"
+	// A rendered output that is not well formed for its type.
+	StageValidate Stage = "validate"
+)
+
+// severity lists the stage of each stage sentinel, most severe — highest exit
+// status — first.
+var severity = []struct {
+	sentinel error
+	stage    Stage
+}{
+	{sentinel: ErrValidateOutput, stage: StageValidate},
+	{sentinel: ErrIterationLimit, stage: StageIterationLimit},
+	{sentinel: ErrOutputLimit, stage: StageOutputLimit},
+	{sentinel: ErrRenderTimeout, stage: StageTimeout},
+	{sentinel: ErrRenderPanic, stage: StagePanic},
+	{sentinel: ErrExecuteTemplate, stage: StageExecute},
+	{sentinel: ErrRequiredKey, stage: StageExecute},
+	{sentinel: ErrNestingLimit, stage: StageExecute},
+	{sentinel: ErrParseTemplate, stage: StageParse},
+	{sentinel: ErrEscapeTemplate, stage: StageParse},
+	{sentinel: ErrParseFrontMatter, stage: StageParse},
+	{sentinel: ErrParseMarkers, stage: StageParse},
+	{sentinel: ErrReadTemplate, stage: StageRead},
+	{sentinel: ErrReadLibrary, stage: StageRead},
+}
+
+// StageOf returns the stage err failed in: the most severe stage among the
+// sentinels it wraps — all of them, for failures collected with errors.Join —
+// or StageGeneric when it wraps none.
+func StageOf(err error) Stage {
+	for _, s := range severity {
+		if errors.Is(err, s.sentinel) {
+			return s.stage
+		}
+	}
+	return StageGeneric
+}
+
+// TemplateError locates a template failure: the template it occurred in, the
+// one-based line and column of the failing action (zero when unknown), that
+// line of the template source, the field path of the action, when there was
+// one, and the reason text/template gave without its position prefix. A
+// Suggestion, when set, is the name the template most likely meant. It wraps
+// the sentinel-bearing Cause, whose message it reports unchanged, so it can be
+// added without changing what errors.Is or a reader of the message sees.
+type TemplateError struct {
+	Cause      error
+	Template   string
+	Source     string
+	Path       string
+	Reason     string
+	Suggestion string
+	Line       int
+	Column     int
+}
+
+// Error returns the message of the wrapped cause.
+func (e *TemplateError) Error() string {
+	return e.Cause.Error()
+}
+
+// Unwrap returns the wrapped cause.
+func (e *TemplateError) Unwrap() error {
+	return e.Cause
+}
+
+// Stage returns the stage the wrapped cause failed in.
+func (e *TemplateError) Stage() Stage {
+	return StageOf(e.Cause)
+}
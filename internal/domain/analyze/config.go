@@ -5,7 +5,10 @@ import "io"
 // Config holds the analyze inputs: the template to analyze and the injected IO
 // seams. It carries no behavior.
 type Config struct {
-	Source   io.Reader
-	ReadFile ReadFileFunc
-	Template TemplateFile
+	Source          io.Reader
+	ReadFile        ReadFileFunc
+	Template        TemplateFile
+	Delims          TemplateDelims
+	HTMLEnabled     HTMLEnabled
+	HTMLAutoEnabled HTMLAutoEnabled
 }
@@ -4,9 +4,11 @@ import (
 	"context"
 	"io"
 	"log/slog"
+	"strings"
 
 	"github.com/gomatic/renderizer/internal/constants"
 	"github.com/gomatic/renderizer/internal/domain"
+	"github.com/gomatic/renderizer/internal/frontmatter"
 	"github.com/gomatic/renderizer/internal/inspect"
 	"github.com/gomatic/renderizer/internal/template"
 )
@@ -16,20 +18,48 @@ type Result struct {
 	Output []byte
 }
 
-// Run reads the template and infers its input data model, returning the YAML
-// skeleton. Template functions are only needed so parsing succeeds — the
-// analysis never executes the template — so the default function set is used.
+// Run reads the template and infers its input data model, parsing its body
+// with the delimiters its front matter declares, or else the configured ones,
+// and as HTML when so configured, and returns the YAML skeleton, holding the
+// defaults the front matter declares. Template functions are only needed so
+// parsing succeeds — the analysis never executes the template — so the
+// default function set is used.
 func Run(_ context.Context, logger *slog.Logger, cfg Config, _ ...domain.Argument) (Result, error) {
 	source, name, err := read(cfg)
 	if err != nil {
 		return Result{}, err
 	}
-	model, err := inspect.Analyze(template.Funcs(false), inspect.Name(name), source)
+	matter, body, err := frontmatter.Split(source)
 	if err != nil {
 		return Result{}, err
 	}
+	delims, err := template.ParseDelims(string(delimsOf(cfg, matter)))
+	if err != nil {
+		return Result{}, err
+	}
+	syntax := template.Syntax{Delims: delims, IsHTML: template.HTMLEnabled(isHTML(cfg, name))}
+	model, err := inspect.Analyze(template.Funcs(false), syntax, inspect.Name(name), body)
+	if err != nil {
+		return Result{}, matter.Shift(name, err)
+	}
 	logger.Debug("Analyzed template.", "template", name)
-	return Result{Output: inspect.Skeleton(model)}, nil
+	return Result{Output: inspect.SkeletonWith(model, matter.Defaults)}, nil
+}
+
+// delimsOf returns the action delimiters the front matter declares, or else
+// the --delims ones.
+func delimsOf(cfg Config, matter frontmatter.Matter) TemplateDelims {
+	if matter.Delims != "" {
+		return TemplateDelims(matter.Delims)
+	}
+	return cfg.Delims
+}
+
+// isHTML reports whether the template named name is parsed as HTML: under
+// --html, or under --html-auto when it is named *.html.tmpl.
+func isHTML(cfg Config, name string) bool {
+	return bool(cfg.HTMLEnabled) ||
+		bool(cfg.HTMLAutoEnabled) && cfg.Template != "" && strings.HasSuffix(name, ".html.tmpl")
 }
 
 // read returns the template bytes and a display name from a file or stdin.
@@ -74,3 +74,43 @@ func TestRunParseError(t *testing.T) {
 	_, err := run(t, cfg)
 	require.ErrorIs(t, err, constants.ErrParseTemplate)
 }
+
+func TestRunDelims(t *testing.T) {
+	t.Parallel()
+	cfg := analyze.Config{Source: strings.NewReader("[[ .Name ]] {{ .Other }}"), Delims: "[[,]]"}
+	result, err := run(t, cfg)
+	require.NoError(t, err)
+	assert.Equal(t, "Name: \"\"\n", string(result.Output))
+
+	cfg.Delims = "[["
+	_, err = run(t, cfg)
+	require.ErrorIs(t, err, constants.ErrParseDelims)
+}
+
+func TestRunHTMLAuto(t *testing.T) {
+	t.Parallel()
+	cfg := analyze.Config{
+		Template:        "page.html.tmpl",
+		HTMLAutoEnabled: true,
+		ReadFile:        func(string) ([]byte, error) { return []byte(`<a href="{{ .Url }}">`), nil },
+	}
+	result, err := run(t, cfg)
+	require.NoError(t, err)
+	assert.Equal(t, "Url: \"\"\n", string(result.Output))
+}
+
+func TestRunFrontMatter(t *testing.T) {
+	t.Parallel()
+	cfg := analyze.Config{
+		Source: strings.NewReader("---\ndefaults:\n  Port: 80\n  Image:\n    Tag: latest\ndelims: '[[,]]'\n---\n" +
+			"[[ .Name ]]:[[ .Port ]] [[ .Image.Name ]]"),
+		Delims: "<<,>>",
+	}
+	result, err := run(t, cfg)
+	require.NoError(t, err)
+	assert.Equal(t, "Image:\n    Name: \"\"\n    Tag: latest\nName: \"\"\nPort: 80\n", string(result.Output))
+
+	cfg.Source = strings.NewReader("---\ndelims: '[['\n---\n")
+	_, err = run(t, cfg)
+	require.ErrorIs(t, err, constants.ErrParseDelims)
+}
@@ -4,6 +4,14 @@ package analyze
 type (
 	// TemplateFile is the template path to analyze; empty means read stdin.
 	TemplateFile string
+	// TemplateDelims are the left and right action delimiters, comma-separated
+	// (--delims).
+	TemplateDelims string
+	// HTMLEnabled parses the template with html/template (--html).
+	HTMLEnabled bool
+	// HTMLAutoEnabled parses the template with html/template when it is named
+	// *.html.tmpl (--html-auto).
+	HTMLAutoEnabled bool
 )
 
 // ReadFileFunc reads a named file. os.ReadFile satisfies it in production.
@@ -7,20 +7,48 @@ import "io"
 // root supplies. It carries no behavior. The boolean fields are named for the
 // enabled state they toggle, matching their named types.
 type Config struct {
-	Source            io.Reader
-	Environ           EnvironFunc
-	Getwd             GetwdFunc
-	Exists            ExistsFunc
-	ReadFile          ReadFileFunc
-	TimeFormat        TimeFormat
-	Environment       EnvironmentName
-	MissingKey        MissingKeyOption
-	Settings          SettingsFiles
-	Assignments       AssignmentTokens
-	Templates         TemplateFiles
-	VerboseEnabled    VerboseEnabled
-	CapitalizeEnabled Capitalization
-	DebuggingEnabled  DebuggingEnabled
-	TestingEnabled    TestingEnabled
-	StdinEnabled      StdinEnabled
+	Source                  io.Reader
+	Environ                 EnvironFunc
+	Getwd                   GetwdFunc
+	Exists                  ExistsFunc
+	ReadFile                ReadFileFunc
+	WriteFile               WriteFileFunc
+	ReadDir                 ReadDirFunc
+	Glob                    GlobFunc
+	TimeFormat              TimeFormat
+	Environment             EnvironmentName
+	EnvironmentPrefix       EnvironmentPrefix
+	EnvironmentAllow        EnvironmentPatterns
+	EnvironmentDeny         EnvironmentPatterns
+	MissingKey              MissingKeyOption
+	Delims                  TemplateDelims
+	Merge                   MergeStrategy
+	Settings                SettingsFiles
+	Profile                 ProfileName
+	Assignments             AssignmentTokens
+	Templates               TemplateFiles
+	Library                 LibraryPaths
+	InPlace                 InPlaceFiles
+	AllowedFuncs            FuncNames
+	DeniedFuncs             FuncNames
+	Timeout                 RenderTimeout
+	MaxOutput               OutputLimit
+	MaxIterations           IterationLimit
+	Seed                    TestSeed
+	Now                     TestClock
+	VerboseEnabled          VerboseEnabled
+	CapitalizeEnabled       Capitalization
+	DebuggingEnabled        DebuggingEnabled
+	TestingEnabled          TestingEnabled
+	StdinEnabled            StdinEnabled
+	ExplainEnabled          ExplainEnabled
+	InferenceDisabled       InferenceDisabled
+	TemplateSettingsEnabled TemplateSettingsEnabled
+	KeepGoingEnabled        KeepGoingEnabled
+	ValidateOutputEnabled   ValidateOutputEnabled
+	FormatOutputEnabled     FormatOutputEnabled
+	CheckEnabled            CheckEnabled
+	SandboxEnabled          SandboxEnabled
+	HTMLEnabled             HTMLEnabled
+	HTMLAutoEnabled         HTMLAutoEnabled
 }
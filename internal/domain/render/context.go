@@ -1,11 +1,15 @@
 package render
 
 import (
+	"maps"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/gomatic/renderizer/internal/environment"
+	"github.com/gomatic/renderizer/internal/frontmatter"
 	"github.com/gomatic/renderizer/internal/settings"
+	"github.com/gomatic/renderizer/internal/template"
 	"github.com/gomatic/renderizer/internal/variables"
 )
 
@@ -15,26 +19,124 @@ import (
 // wins, and getting that wrong silently renders the wrong output.
 
 // buildContext assembles the template data: command-line variables, then
-// settings (which only fill names the variables did not set), then the
-// environment map.
+// prefixed environment variables, then settings — each only filling names the
+// ones before it did not set — then the environment map and renderizer's own
+// run details.
 func buildContext(cfg Config) (variables.Context, error) {
-	format := variables.TimeFormat(cfg.TimeFormat)
-	data, err := variables.Assignments(cfg.Assignments, variables.Capitalization(cfg.CapitalizeEnabled), format)
+	if err := environmentFilter(cfg).Validate(); err != nil {
+		return nil, err
+	}
+	if _, err := template.ParseDelims(string(cfg.Delims)); err != nil {
+		return nil, err
+	}
+	data, err := assignments(cfg)
+	if err != nil {
+		return nil, err
+	}
+	prefixed, err := prefixedEnvironment(cfg)
 	if err != nil {
 		return nil, err
 	}
-	loaded, err := settings.Load(settings.ReadFile(cfg.ReadFile), settingsFiles(cfg), format)
+	mergeDefaults(data, prefixed)
+	loaded, err := settingsLoader(cfg, data).Load(settingsFiles(cfg))
 	if err != nil {
 		return nil, err
 	}
 	mergeDefaults(data, loaded)
 	addEnvironment(cfg, data)
+	addRunDetails(cfg, data)
 	return data, nil
 }
 
+// assignments returns the context the command-line assignments define, reading
+// any `@file` values through the injected seam.
+func assignments(cfg Config) (variables.Context, error) {
+	return variables.Assignments(
+		cfg.Assignments,
+		variables.Capitalization(cfg.CapitalizeEnabled),
+		variables.Typing{
+			Read:        variables.ReadFile(cfg.ReadFile),
+			Format:      variables.TimeFormat(cfg.TimeFormat),
+			ShouldInfer: inference(cfg),
+		},
+		mergeStrategy(cfg),
+	)
+}
+
+// prefixedEnvironment returns the context the --env-prefix environment
+// variables define, typed and capitalized as assignments are. It ranks below
+// the command line, which is the more deliberate choice for one run, and
+// above settings files, which are checked-in defaults.
+func prefixedEnvironment(cfg Config) (variables.Context, error) {
+	return variables.Environment(
+		prefixedVariables(cfg),
+		variables.Capitalization(cfg.CapitalizeEnabled),
+		variables.Typing{Format: variables.TimeFormat(cfg.TimeFormat), ShouldInfer: inference(cfg)},
+	)
+}
+
+// prefixedVariables returns the exposed environment variables --env-prefix
+// selects, with the prefix stripped.
+func prefixedVariables(cfg Config) environment.Variables {
+	return exposedEnvironment(cfg).WithPrefix(string(cfg.EnvironmentPrefix))
+}
+
+// exposedEnvironment returns the environment variables templates may see:
+// those --env-allow and --env-deny select. buildContext has validated the
+// patterns.
+func exposedEnvironment(cfg Config) environment.Variables {
+	return environment.Load(environment.Environ(cfg.Environ)).Select(environmentFilter(cfg))
+}
+
+// environmentFilter returns the --env-allow and --env-deny patterns.
+func environmentFilter(cfg Config) environment.Filter {
+	return environment.Filter{Allow: cfg.EnvironmentAllow, Deny: cfg.EnvironmentDeny}
+}
+
+// inference reports whether untyped values have their types guessed.
+func inference(cfg Config) variables.Inference {
+	return variables.Inference(!bool(cfg.InferenceDisabled))
+}
+
+// settingsLoader returns the loader for this run's settings files, reading
+// through the injected seam and interpolating from the environment templates
+// may see, so a settings file cannot reveal a variable --env-deny hides. With
+// templated settings enabled, files render against the command-line
+// assignments and the environment binding, with the main template's functions,
+// missingkey option, and delimiters.
+func settingsLoader(cfg Config, assigned variables.Context) settings.Loader {
+	loader := settings.Loader{
+		Read:        settings.ReadFile(cfg.ReadFile),
+		Environment: exposedEnvironment(cfg),
+		Format:      variables.TimeFormat(cfg.TimeFormat),
+		Strategy:    mergeStrategy(cfg),
+		Inference:   inference(cfg),
+	}
+	if bool(cfg.TemplateSettingsEnabled) {
+		data := maps.Clone(assigned)
+		addEnvironment(cfg, data)
+		loader.Templating = settings.Templating{
+			Funcs:     templateFuncs(cfg),
+			Data:      data,
+			Missing:   template.NormalizeMissingKey(template.MissingKey(cfg.MissingKey)),
+			Delims:    templateDelims(cfg),
+			IsEnabled: true,
+		}
+	}
+	return loader
+}
+
+// mergeStrategy returns the configured strategy for combining settings files
+// and repeated assignments, normalized so an unknown name appends.
+func mergeStrategy(cfg Config) variables.MergeStrategy {
+	return variables.NormalizeMergeStrategy(variables.MergeStrategy(cfg.Merge))
+}
+
 // mergeDefaults fills data with settings values for names the command line did
 // not set, recursing into maps so nested settings fill nested gaps. Command-line
 // variables always win, so a name present in both keeps the command-line value.
+// The merge strategy does not apply here: it decides how values combine within
+// one source, never which source wins.
 func mergeDefaults(data, loaded variables.Context) {
 	for key, value := range loaded {
 		existing, present := data[key]
@@ -56,19 +158,97 @@ func mergeNested(existing, incoming any) {
 	}
 }
 
-// addEnvironment binds the environment map under the configured key.
+// withDefaults returns data over a template's front-matter defaults, which,
+// like settings beneath the command line, fill only the names data leaves
+// unset, recursing into maps. data is shared by every template, so it is left
+// untouched: the defaults, decoded afresh for each render, take data's values
+// instead.
+func withDefaults(data variables.Context, defaults frontmatter.Defaults) variables.Context {
+	if len(defaults) == 0 {
+		return data
+	}
+	merged := map[string]any(defaults)
+	overlay(merged, data)
+	return merged
+}
+
+// overlay sets each value of top in base, merging the maps both hold rather
+// than replacing base's.
+func overlay(base, top map[string]any) {
+	for key, value := range top {
+		baseMap, isMap := base[key].(map[string]any)
+		topMap, topIsMap := value.(map[string]any)
+		if isMap && topIsMap {
+			overlay(baseMap, topMap)
+			continue
+		}
+		base[key] = value
+	}
+}
+
+// addEnvironment binds the exposed environment map under the configured key.
 func addEnvironment(cfg Config, data variables.Context) {
-	if cfg.Environment == "" {
+	if !isEnvironmentBound(cfg) {
 		return
 	}
-	data[string(cfg.Environment)] = map[string]string(environment.Load(environment.Environ(cfg.Environ)))
+	data[string(cfg.Environment)] = map[string]string(exposedEnvironment(cfg))
+}
+
+// isEnvironmentBound reports whether templates see the environment map: when
+// it has a key to be bound under and the run is not sandboxed.
+func isEnvironmentBound(cfg Config) bool {
+	return cfg.Environment != "" && !bool(cfg.SandboxEnabled)
+}
+
+// templateFuncs returns the functions this run's templates may call: the
+// standard set, reproducible in testing mode, restricted by the sandbox and
+// the --allow-func and --deny-func names. Testing mode draws from the --seed
+// sequence, fixes the clock at --now and reads only the environment templates
+// may see.
+func templateFuncs(cfg Config) map[string]any {
+	policy := template.FuncPolicy{
+		Allow:       template.FuncNames(cfg.AllowedFuncs),
+		Deny:        template.FuncNames(cfg.DeniedFuncs),
+		IsSandboxed: template.SandboxEnabled(cfg.SandboxEnabled),
+	}
+	return policy.Apply(template.FuncsWith(template.Testing{
+		Now:         time.Time(cfg.Now),
+		Environment: exposedEnvironment(cfg),
+		Seed:        template.Seed(cfg.Seed),
+		IsEnabled:   template.TestingEnabled(cfg.TestingEnabled),
+	}))
 }
 
-// settingsFiles returns the explicit --settings files, or the optional implicit
-// default when none were given.
+// templateDelims returns the action delimiters --delims sets for this run's
+// templates. buildContext has validated them.
+func templateDelims(cfg Config) template.Delims {
+	delims, _ := template.ParseDelims(string(cfg.Delims))
+	return delims
+}
+
+// runDetailsKey is the context key renderizer's own run details are bound
+// under. It is lower-case, so a capitalized command-line name never collides
+// with it.
+const runDetailsKey = "renderizer"
+
+// addRunDetails binds the details of this run templates may branch on, such as
+// the active profile (empty when none), under runDetailsKey.
+func addRunDetails(cfg Config, data variables.Context) {
+	data[runDetailsKey] = map[string]any{"profile": string(cfg.Profile)}
+}
+
+// settingsFiles returns the base settings — the explicit --settings files, or
+// the discovered implicit ones when none were given — followed by the
+// optional overlays.
 func settingsFiles(cfg Config) []settings.File {
+	return append(baseSettings(cfg), overlays(cfg)...)
+}
+
+// baseSettings returns the explicit --settings files, or the discovered
+// implicit ones when none were given.
+func baseSettings(cfg Config) []settings.File {
 	if len(cfg.Settings) == 0 {
-		return []settings.File{{Path: "." + mainName(cfg) + ".yaml", IsOptional: true}}
+		return discoveredSettings(cfg)
 	}
 	files := make([]settings.File, len(cfg.Settings))
 	for i, path := range cfg.Settings {
@@ -77,6 +257,33 @@ func settingsFiles(cfg Config) []settings.File {
 	return files
 }
 
+// overlays returns the optional overlay files, in the order they apply: the
+// selected profile's `.<name>.<profile>.yaml`, then the untracked
+// `.<name>.local.yaml`. Each overrides the files before it.
+func overlays(cfg Config) []settings.File {
+	name := mainName(cfg)
+	strategy := overlayStrategy(cfg)
+	var files []settings.File
+	if cfg.Profile != "" {
+		files = append(files, settings.File{
+			Path:       "." + name + "." + string(cfg.Profile) + ".yaml",
+			Strategy:   strategy,
+			IsOptional: true,
+		})
+	}
+	return append(files, settings.File{Path: "." + name + ".local.yaml", Strategy: strategy, IsOptional: true})
+}
+
+// overlayStrategy is how an overlay merges onto its base. An overlay exists to
+// override, so under a strategy that keeps existing values it replaces them
+// instead; an overriding strategy is used as configured.
+func overlayStrategy(cfg Config) variables.MergeStrategy {
+	if strategy := mergeStrategy(cfg); variables.IsOverriding(strategy) {
+		return strategy
+	}
+	return variables.MergeReplace
+}
+
 // mainName derives the base name for the default settings file from the first
 // template, the working directory, or the fallback.
 func mainName(cfg Config) string {
@@ -90,9 +297,10 @@ func mainName(cfg Config) string {
 	return defaultBase
 }
 
-// templateSource names a single render input: an explicit/discovered file, or
-// stdin.
+// templateSource names a single render input: an explicit/discovered file,
+// stdin, or an inline template, named for the file holding it.
 type templateSource struct {
 	name    string
+	inline  []byte
 	isStdin bool
 }
@@ -40,10 +40,71 @@ func TestMergeDefaultsLetsCommandLineVariablesAlwaysWin(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t,
 		"from-command-line|from-settings|from-command-line|from-settings\n",
-		string(result.Output),
+		string(result.Output()),
 		"command-line values win at every depth; settings fill only the gaps")
 }
 
+// TestMergeStrategyNeverChangesWhichSourceWins pins the boundary of --merge:
+// it decides how lists combine within settings, but a command-line value still
+// beats every settings file whatever the strategy.
+func TestMergeStrategyNeverChangesWhichSourceWins(t *testing.T) {
+	t.Parallel()
+	cfg := baseConfig()
+	cfg.Merge = "replace"
+	cfg.StdinEnabled = true
+	cfg.Source = strings.NewReader("{{.Name}}|{{range .Items}}{{.}}{{end}}")
+	cfg.Settings = render.SettingsFiles{"base.yaml", "overlay.yaml"}
+	cfg.ReadFile = mapReadFile(map[string]string{
+		"base.yaml":    "Name: base\nItems: [a, b]\n",
+		"overlay.yaml": "Name: overlay\nItems: [c]\n",
+	})
+	cfg.Assignments = render.AssignmentTokens{"--name=cli"}
+
+	result, err := run(t, cfg)
+
+	require.NoError(t, err)
+	assert.Equal(t, "cli|c\n", string(result.Output()))
+}
+
+// TestProfileOverlaysOverrideTheBaseInOrder pins the overlay order: the
+// default settings, then the profile's file, then the local file, each
+// overriding the one before even under the default append strategy — an
+// overlay that could only fill gaps would be unable to change any value the
+// base already sets.
+func TestProfileOverlaysOverrideTheBaseInOrder(t *testing.T) {
+	t.Parallel()
+	cfg := baseConfig()
+	cfg.Profile = "prod"
+	cfg.Templates = render.TemplateFiles{"app.tmpl"}
+	cfg.ReadFile = mapReadFile(map[string]string{
+		"app.tmpl":        "{{.renderizer.profile}}:{{.Replicas}}:{{.Region}}:{{.Owner}}:{{range .Hosts}}{{.}}{{end}}",
+		".app.yaml":       "Replicas: 1\nRegion: us\nOwner: team\nHosts: [a, b]\n",
+		".app.prod.yaml":  "Replicas: 3\nRegion: eu\nHosts: [c]\n",
+		".app.local.yaml": "Region: local\n",
+	})
+
+	result, err := run(t, cfg)
+
+	require.NoError(t, err)
+	assert.Equal(t, "prod:3:local:team:c\n", string(result.Output()))
+}
+
+func TestProfileOverlaysAreOptional(t *testing.T) {
+	t.Parallel()
+	cfg := baseConfig()
+	cfg.Profile = "staging"
+	cfg.Templates = render.TemplateFiles{"app.tmpl"}
+	cfg.ReadFile = mapReadFile(map[string]string{
+		"app.tmpl":  "{{.renderizer.profile}}:{{.Replicas}}",
+		".app.yaml": "Replicas: 1\n",
+	})
+
+	result, err := run(t, cfg)
+
+	require.NoError(t, err)
+	assert.Equal(t, "staging:1\n", string(result.Output()))
+}
+
 func TestRunSettingsProvidesValue(t *testing.T) {
 	t.Parallel()
 	cfg := baseConfig()
@@ -56,7 +117,7 @@ func TestRunSettingsProvidesValue(t *testing.T) {
 
 	result, err := run(t, cfg)
 	require.NoError(t, err)
-	assert.Equal(t, "FromSettings\n", string(result.Output))
+	assert.Equal(t, "FromSettings\n", string(result.Output()))
 }
 
 func TestRunVariablesOverrideSettings(t *testing.T) {
@@ -72,7 +133,7 @@ func TestRunVariablesOverrideSettings(t *testing.T) {
 
 	result, err := run(t, cfg)
 	require.NoError(t, err)
-	assert.Equal(t, "FromCLI\n", string(result.Output))
+	assert.Equal(t, "FromCLI\n", string(result.Output()))
 }
 
 func TestRunSettingsDeepMerge(t *testing.T) {
@@ -89,7 +150,7 @@ func TestRunSettingsDeepMerge(t *testing.T) {
 
 	result, err := run(t, cfg)
 	require.NoError(t, err)
-	assert.Equal(t, "cli-settings\n", string(result.Output))
+	assert.Equal(t, "cli-settings\n", string(result.Output()))
 }
 
 func TestRunSettingsParseError(t *testing.T) {
@@ -123,5 +184,152 @@ func TestRunStdinEnvironment(t *testing.T) {
 
 	result, err := run(t, cfg)
 	require.NoError(t, err)
-	assert.Equal(t, "alice\n", string(result.Output))
+	assert.Equal(t, "alice\n", string(result.Output()))
+}
+
+func TestRunTemplateSettingsRendersAgainstVariablesAndEnvironment(t *testing.T) {
+	t.Parallel()
+	cfg := baseConfig()
+	cfg.TemplateSettingsEnabled = true
+	cfg.Settings = render.SettingsFiles{"s.yaml"}
+	cfg.Templates = render.TemplateFiles{"t.tmpl"}
+	cfg.ReadFile = mapReadFile(map[string]string{
+		"s.yaml": "Image: '{{ .Repo }}/{{ .App }}'\nOwner: '{{ .env.USER }}'\nApp: web\n",
+		"t.tmpl": "{{.Image}} by {{.Owner}}",
+	})
+	cfg.Assignments = render.AssignmentTokens{"--repo=ghcr.io"}
+
+	result, err := run(t, cfg)
+
+	require.NoError(t, err)
+	assert.Equal(t, "ghcr.io/web by alice\n", string(result.Output()))
+}
+
+func TestRunTemplateSettingsErrorIsASettingsError(t *testing.T) {
+	t.Parallel()
+	cfg := baseConfig()
+	cfg.TemplateSettingsEnabled = true
+	cfg.StdinEnabled = true
+	cfg.Source = strings.NewReader("x")
+	cfg.Settings = render.SettingsFiles{"s.yaml"}
+	cfg.ReadFile = mapReadFile(map[string]string{"s.yaml": "a: '{{ .Missing }}'"})
+
+	_, err := run(t, cfg)
+
+	require.ErrorIs(t, err, constants.ErrParseSettings)
+	require.NotErrorIs(t, err, constants.ErrExecuteTemplate)
+	assert.Contains(t, err.Error(), "s.yaml")
+}
+
+func TestRunNoInferKeepsVariablesAndSettingsAsStrings(t *testing.T) {
+	t.Parallel()
+	cfg := baseConfig()
+	cfg.InferenceDisabled = true
+	cfg.Settings = render.SettingsFiles{"s.yaml"}
+	cfg.Templates = render.TemplateFiles{"t.tmpl"}
+	cfg.ReadFile = mapReadFile(map[string]string{
+		"s.yaml": "Version: '1.10'\n",
+		"t.tmpl": "{{.Zip}} {{.Version}} {{printf \"%T\" .Port}} {{.Cfg.replicas}}",
+		"c.json": `{"replicas": 2}`,
+	})
+	cfg.Assignments = render.AssignmentTokens{"--zip=02134", "--port:int=80", "--cfg:json=@c.json"}
+
+	result, err := run(t, cfg)
+
+	require.NoError(t, err)
+	assert.Equal(t, "02134 1.10 int64 2\n", string(result.Output()))
+}
+
+func TestRunIndexedAssignmentsBuildListsOfMaps(t *testing.T) {
+	t.Parallel()
+	cfg := baseConfig()
+	cfg.Settings = render.SettingsFiles{"s.yaml"}
+	cfg.Templates = render.TemplateFiles{"t.tmpl"}
+	cfg.ReadFile = mapReadFile(map[string]string{
+		"s.yaml": "Containers:\n- Name: settings\n",
+		"t.tmpl": `{{range .Containers}}{{.Name}};{{end}}{{index .Labels "app.kubernetes.io/name"}}`,
+	})
+	cfg.Assignments = render.AssignmentTokens{
+		"--containers[0].name=web",
+		"--containers[1].name=db",
+		`--labels."app.kubernetes.io/name"=shop`,
+	}
+
+	result, err := run(t, cfg)
+
+	require.NoError(t, err)
+	assert.Equal(t, "web;db;shop\n", string(result.Output()))
+}
+
+// TestRunPrefixedEnvironmentRanksBetweenCommandLineAndSettings pins the
+// precedence of --env-prefix variables: a command-line assignment beats them,
+// and they beat settings files.
+func TestRunPrefixedEnvironmentRanksBetweenCommandLineAndSettings(t *testing.T) {
+	t.Parallel()
+	cfg := baseConfig()
+	cfg.EnvironmentPrefix = "RNDR_"
+	cfg.Environ = func() []string {
+		return []string{"RNDR_NAME=env", "RNDR_REGION=env", "RNDR_ITEMS__0=a", "RNDR_ITEMS__1=b", "OTHER_X=1"}
+	}
+	cfg.Settings = render.SettingsFiles{"s.yaml"}
+	cfg.Templates = render.TemplateFiles{"t.tmpl"}
+	cfg.ReadFile = mapReadFile(map[string]string{
+		"s.yaml": "Name: settings\nRegion: settings\nZone: settings\n",
+		"t.tmpl": "{{.Name}} {{.Region}} {{.Zone}} {{.Items}} {{.env.OTHER_X}}",
+	})
+	cfg.Assignments = render.AssignmentTokens{"--name=cli"}
+
+	result, err := run(t, cfg)
+
+	require.NoError(t, err)
+	assert.Equal(t, "cli env settings [a b] 1\n", string(result.Output()))
+}
+
+func TestRunEnvironmentAllowAndDenyFilterTheEnvironment(t *testing.T) {
+	t.Parallel()
+	cfg := baseConfig()
+	cfg.EnvironmentAllow = render.EnvironmentPatterns{"CI_*", "HOME"}
+	cfg.EnvironmentDeny = render.EnvironmentPatterns{"*_INTERNAL"}
+	cfg.EnvironmentPrefix = "CI_"
+	cfg.Environ = func() []string {
+		return []string{"CI_JOB=7", "CI_KEY_INTERNAL=x", "HOME=/home", "USER=alice"}
+	}
+	cfg.Templates = render.TemplateFiles{"t.tmpl"}
+	cfg.ReadFile = mapReadFile(map[string]string{"t.tmpl": "{{.env}} {{.Job}} {{.Key_internal}}"})
+	cfg.MissingKey = "zero"
+
+	result, err := run(t, cfg)
+
+	require.NoError(t, err)
+	assert.Equal(t, "map[CI_JOB:7 HOME:/home] 7 <no value>\n", string(result.Output()))
+}
+
+func TestRunEnvironmentDenyFiltersSettingsInterpolation(t *testing.T) {
+	t.Parallel()
+	cfg := baseConfig()
+	cfg.EnvironmentDeny = render.EnvironmentPatterns{"*_TOKEN"}
+	cfg.Environ = func() []string { return []string{"CI_TOKEN=secret", "CI_JOB=7"} }
+	cfg.Settings = render.SettingsFiles{"s.yaml"}
+	cfg.Templates = render.TemplateFiles{"t.tmpl"}
+	cfg.ReadFile = mapReadFile(map[string]string{
+		"s.yaml": "Token: ${CI_TOKEN:-hidden}\nJob: ${CI_JOB}\n",
+		"t.tmpl": "{{.Token}} {{.Job}}",
+	})
+
+	result, err := run(t, cfg)
+
+	require.NoError(t, err)
+	assert.Equal(t, "hidden 7\n", string(result.Output()))
+}
+
+func TestRunEnvironmentPatternError(t *testing.T) {
+	t.Parallel()
+	cfg := baseConfig()
+	cfg.EnvironmentDeny = render.EnvironmentPatterns{"[bad"}
+	cfg.StdinEnabled = true
+	cfg.Source = strings.NewReader("x")
+
+	_, err := run(t, cfg)
+
+	require.ErrorIs(t, err, constants.ErrEnvironmentPattern)
 }
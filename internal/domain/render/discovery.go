@@ -0,0 +1,107 @@
+package render
+
+import (
+	"path/filepath"
+	"slices"
+
+	"github.com/gomatic/renderizer/internal/environment"
+	"github.com/gomatic/renderizer/internal/settings"
+)
+
+// Discovering the implicit settings files: the user's own defaults, then every
+// directory from the repository root down to the working directory, so a
+// monorepo can share organization-wide defaults that each service overrides
+// locally. Which files exist is decided by the injected seams, never the real
+// filesystem, so discovery is testable with fakes.
+
+// repositoryMarker marks a repository root, where the upward walk stops.
+const repositoryMarker = ".git"
+
+// discoveredSettings returns the implicit settings files, lowest precedence
+// first: the user-level files under the XDG config directory, then
+// `.renderizer.yaml` and `.<name>.yaml` in each directory from the repository
+// (or filesystem) root down to the working directory. Every file is optional,
+// and each overrides the ones before it, so the closest file wins.
+func discoveredSettings(cfg Config) []settings.File {
+	name := mainName(cfg)
+	paths := append(userSettings(cfg, name), treeSettings(cfg, name)...)
+	strategy := overlayStrategy(cfg)
+	files := make([]settings.File, len(paths))
+	for i, path := range paths {
+		files[i] = settings.File{Path: path, IsOptional: true}
+		if i > 0 {
+			files[i].Strategy = strategy
+		}
+	}
+	return files
+}
+
+// userSettings returns the user-level settings files, or none when no config
+// directory can be determined.
+func userSettings(cfg Config, name string) []string {
+	home := configHome(environment.Load(environment.Environ(cfg.Environ)))
+	if home == "" {
+		return nil
+	}
+	dir := filepath.Join(home, defaultBase)
+	paths := []string{filepath.Join(dir, defaultBase+".yaml")}
+	if name != defaultBase {
+		paths = append(paths, filepath.Join(dir, name+".yaml"))
+	}
+	return paths
+}
+
+// configHome returns $XDG_CONFIG_HOME, falling back to $HOME/.config as the
+// XDG base directory specification requires, or empty when neither is set.
+func configHome(env environment.Variables) string {
+	if home := env["XDG_CONFIG_HOME"]; home != "" {
+		return home
+	}
+	if home := env["HOME"]; home != "" {
+		return filepath.Join(home, ".config")
+	}
+	return ""
+}
+
+// treeSettings returns the settings files of each ancestor directory, root-most
+// first, then the working directory's own, which stay relative as they always
+// have been.
+func treeSettings(cfg Config, name string) []string {
+	var paths []string
+	for _, dir := range ancestors(cfg) {
+		paths = append(paths, directorySettings(dir, name)...)
+	}
+	return append(paths, directorySettings("", name)...)
+}
+
+// directorySettings returns the generic `.renderizer.yaml` and the specific
+// `.<name>.yaml` in dir, the specific one last so it wins.
+func directorySettings(dir, name string) []string {
+	paths := []string{filepath.Join(dir, "."+defaultBase+".yaml")}
+	if name != defaultBase {
+		paths = append(paths, filepath.Join(dir, "."+name+".yaml"))
+	}
+	return paths
+}
+
+// ancestors returns the directories above the working directory, root-most
+// first, up to and including the nearest repository root, or the filesystem
+// root when there is none. A working directory that is itself a repository
+// root, or that cannot be determined, has none.
+func ancestors(cfg Config) []string {
+	dir, err := cfg.Getwd()
+	if err != nil {
+		return nil
+	}
+	var dirs []string
+	for !cfg.Exists(filepath.Join(dir, repositoryMarker)) {
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dirs = append(dirs, parent)
+		dir = parent
+	}
+	slices.Reverse(dirs)
+	return dirs
+}
@@ -0,0 +1,73 @@
+package render_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gomatic/renderizer/internal/domain/render"
+)
+
+// TestDiscoveredSettingsLetTheClosestFileWin pins hierarchical discovery: the
+// user's XDG defaults, then each directory from the repository root down to
+// the working directory, every closer file overriding the further ones. The
+// walk stops at the repository root, so a settings file above it never leaks
+// into the render.
+func TestDiscoveredSettingsLetTheClosestFileWin(t *testing.T) {
+	t.Parallel()
+	cfg := baseConfig()
+	cfg.Getwd = func() (string, error) { return "/outside/repo/services/api", nil }
+	cfg.Exists = existsIn("/outside/repo/.git")
+	cfg.Environ = func() []string { return []string{"XDG_CONFIG_HOME=/xdg"} }
+	cfg.Templates = render.TemplateFiles{"api.tmpl"}
+	cfg.ReadFile = mapReadFile(map[string]string{
+		"api.tmpl": "{{.Org}}|{{.Editor}}|{{.Team}}|{{.Region}}|{{.Port}}|" +
+			`{{if hasKey . "Leak"}}leaked{{end}}`,
+		"/xdg/renderizer/renderizer.yaml":         "Org: personal\nEditor: vim\n",
+		"/outside/.renderizer.yaml":               "Leak: true\n",
+		"/outside/repo/.renderizer.yaml":          "Org: acme\nTeam: platform\nRegion: us\n",
+		"/outside/repo/services/.renderizer.yaml": "Team: services\n",
+		"/outside/repo/services/.api.yaml":        "Port: 80\n",
+		".renderizer.yaml":                        "Port: 8080\n",
+		".api.yaml":                               "Region: eu\n",
+	})
+
+	result, err := run(t, cfg)
+
+	require.NoError(t, err)
+	assert.Equal(t, "acme|vim|services|eu|8080|\n", string(result.Output()))
+}
+
+func TestDiscoveredSettingsFallBackToHomeConfig(t *testing.T) {
+	t.Parallel()
+	cfg := baseConfig()
+	cfg.Environment = ""
+	cfg.Templates = render.TemplateFiles{"api.tmpl"}
+	cfg.ReadFile = mapReadFile(map[string]string{
+		"api.tmpl":                          "{{.Editor}}",
+		"/home/.config/renderizer/api.yaml": "Editor: emacs\n",
+	})
+
+	result, err := run(t, cfg)
+
+	require.NoError(t, err)
+	assert.Equal(t, "emacs\n", string(result.Output()))
+}
+
+func TestExplicitSettingsSkipDiscovery(t *testing.T) {
+	t.Parallel()
+	cfg := baseConfig()
+	cfg.Settings = render.SettingsFiles{"s.yaml"}
+	cfg.Templates = render.TemplateFiles{"api.tmpl"}
+	cfg.ReadFile = mapReadFile(map[string]string{
+		"api.tmpl":         `{{.Name}}{{if hasKey . "Port"}}leaked{{end}}`,
+		"s.yaml":           "Name: explicit\n",
+		".renderizer.yaml": "Port: 8080\n",
+	})
+
+	result, err := run(t, cfg)
+
+	require.NoError(t, err)
+	assert.Equal(t, "explicit\n", string(result.Output()))
+}
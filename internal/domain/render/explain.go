@@ -0,0 +1,92 @@
+package render
+
+import (
+	"github.com/gomatic/renderizer/internal/provenance"
+	"github.com/gomatic/renderizer/internal/variables"
+)
+
+// Explaining the data context: which source supplied every value and which
+// lower-priority values it shadowed. The origins are recorded in the same
+// precedence buildContext applies — command line, then prefixed environment
+// variables, then settings files in order, then the environment map — so the
+// explanation cannot disagree with the merge it describes.
+
+// explain returns the merged context annotated with each leaf's provenance,
+// with likely credentials redacted.
+func explain(cfg Config, data variables.Context) (Result, error) {
+	trace := provenance.NewTrace()
+	traceAssignments(cfg, trace)
+	tracePrefixedEnvironment(cfg, trace)
+	if err := traceSettings(cfg, trace); err != nil {
+		return Result{}, err
+	}
+	traceEnvironment(cfg, trace)
+	trace.Claim([]string{runDetailsKey, "profile"}, provenance.Flag("profile"))
+	return Result{Outputs: []Output{{Content: provenance.Explain(trace.Entries(redact(data)))}}}, nil
+}
+
+// traceAssignments records each command-line assignment. Every assignment is
+// a list element, so repeated names combine as lists do under the strategy.
+func traceAssignments(cfg Config, trace provenance.Trace) {
+	strategy := mergeStrategy(cfg)
+	for _, path := range variables.Paths(cfg.Assignments, variables.Capitalization(cfg.CapitalizeEnabled)) {
+		record(trace, path.Names, provenance.Argument(path.Index, redactToken(path.Token, path.Names)), strategy, true)
+	}
+}
+
+// tracePrefixedEnvironment records each --env-prefix variable under the path
+// it sets, naming the variable as it appears in the environment. Variable
+// names are unique, so two share a path only as elements of one list, which
+// they build together.
+func tracePrefixedEnvironment(cfg Config, trace provenance.Trace) {
+	paths := variables.EnvironmentPaths(prefixedVariables(cfg), variables.Capitalization(cfg.CapitalizeEnabled))
+	for _, path := range paths {
+		trace.Append(path.Names, provenance.Environment(string(cfg.EnvironmentPrefix)+path.Token))
+	}
+}
+
+// traceSettings records every settings leaf by file and line, under the
+// strategy its tags declared or the configured one.
+func traceSettings(cfg Config, trace provenance.Trace) error {
+	assigned, err := assignments(cfg)
+	if err != nil {
+		return err
+	}
+	leaves, err := settingsLoader(cfg, assigned).Origins(settingsFiles(cfg))
+	if err != nil {
+		return err
+	}
+	for _, leaf := range leaves {
+		strategy := mergeStrategy(cfg)
+		if leaf.Strategy != "" {
+			strategy = leaf.Strategy
+		}
+		record(trace, leaf.Path, provenance.Setting(leaf.File, leaf.Line), strategy, leaf.IsList)
+	}
+	return nil
+}
+
+// record traces one origin as the merge strategy combines it: overriding
+// strategies supersede earlier values (except lists merged index by index,
+// which both contribute to), and the appending strategies join lists and keep
+// the first scalar.
+func record(trace provenance.Trace, path []string, origin provenance.Origin, strategy variables.MergeStrategy, isList bool) {
+	switch {
+	case isList && strategy != variables.MergeReplace:
+		trace.Append(path, origin)
+	case variables.IsOverriding(strategy):
+		trace.Replace(path, origin)
+	default:
+		trace.Claim(path, origin)
+	}
+}
+
+// traceEnvironment records each environment variable under the binding key.
+func traceEnvironment(cfg Config, trace provenance.Trace) {
+	if !isEnvironmentBound(cfg) {
+		return
+	}
+	for name := range exposedEnvironment(cfg) {
+		trace.Claim([]string{string(cfg.Environment), name}, provenance.Environment(name))
+	}
+}
@@ -0,0 +1,111 @@
+package render_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gomatic/renderizer/internal/constants"
+	"github.com/gomatic/renderizer/internal/domain/render"
+)
+
+// TestRunExplainAttributesEachValueToTheSourceThatWon pins --explain against
+// the merge it describes: the command-line value wins and names the settings
+// line it shadowed, a settings-only value names its file and line, and the
+// environment is attributed by variable. Nothing is rendered.
+func TestRunExplainAttributesEachValueToTheSourceThatWon(t *testing.T) {
+	t.Parallel()
+	cfg := baseConfig()
+	cfg.ExplainEnabled = true
+	cfg.Environ = func() []string { return []string{"USER=alice"} }
+	cfg.Settings = render.SettingsFiles{"s.yaml"}
+	cfg.Templates = render.TemplateFiles{"t.tmpl"}
+	cfg.ReadFile = mapReadFile(map[string]string{
+		"s.yaml": "Name: FromSettings\nOther: kept\n",
+		"t.tmpl": "{{.Name}}",
+	})
+	cfg.Assignments = render.AssignmentTokens{"--name=FromCLI"}
+
+	result, err := run(t, cfg)
+
+	require.NoError(t, err)
+	assert.Equal(t,
+		"Name = \"FromCLI\"  # from argument #0 (--name=FromCLI); shadows settings s.yaml:1\n"+
+			"Other = \"kept\"  # from settings s.yaml:2\n"+
+			"env.USER = \"alice\"  # from environment USER\n"+
+			"renderizer.profile = \"\"  # from flag --profile\n",
+		string(result.Output()))
+}
+
+func TestRunExplainSettingsError(t *testing.T) {
+	t.Parallel()
+	cfg := baseConfig()
+	cfg.ExplainEnabled = true
+	cfg.Settings = render.SettingsFiles{"missing.yaml"}
+	cfg.ReadFile = mapReadFile(map[string]string{})
+
+	_, err := run(t, cfg)
+	require.ErrorIs(t, err, constants.ErrReadSettings)
+}
+
+func TestRunExplainFollowsTheMergeStrategy(t *testing.T) {
+	t.Parallel()
+	cfg := baseConfig()
+	cfg.ExplainEnabled = true
+	cfg.Environment = ""
+	cfg.Merge = "replace"
+	cfg.Settings = render.SettingsFiles{"base.yaml", "prod.yaml"}
+	cfg.ReadFile = mapReadFile(map[string]string{
+		"base.yaml": "Name: base\nItems: [a]\n",
+		"prod.yaml": "Name: prod\nItems: [b]\n",
+	})
+
+	result, err := run(t, cfg)
+
+	require.NoError(t, err)
+	assert.Equal(t,
+		"Items = [b]  # from settings prod.yaml:2; shadows settings base.yaml:2\n"+
+			"Name = \"prod\"  # from settings prod.yaml:1; shadows settings base.yaml:1\n"+
+			"renderizer.profile = \"\"  # from flag --profile\n",
+		string(result.Output()))
+}
+
+func TestRunExplainAttributesPrefixedEnvironmentVariables(t *testing.T) {
+	t.Parallel()
+	cfg := baseConfig()
+	cfg.ExplainEnabled = true
+	cfg.Environment = ""
+	cfg.EnvironmentPrefix = "RNDR_"
+	cfg.Environ = func() []string { return []string{"RNDR_NAME=env", "RNDR_ITEMS__0=a", "RNDR_ITEMS__1=b"} }
+	cfg.Settings = render.SettingsFiles{"s.yaml"}
+	cfg.ReadFile = mapReadFile(map[string]string{"s.yaml": "Name: settings\n"})
+	cfg.Assignments = render.AssignmentTokens{"--name=cli"}
+
+	result, err := run(t, cfg)
+
+	require.NoError(t, err)
+	assert.Equal(t,
+		"Items = [a b]  # from environment RNDR_ITEMS__0, environment RNDR_ITEMS__1\n"+
+			"Name = \"cli\"  # from argument #0 (--name=cli); shadows environment RNDR_NAME, settings s.yaml:1\n"+
+			"renderizer.profile = \"\"  # from flag --profile\n",
+		string(result.Output()))
+}
+
+func TestRunExplainRedactsCredentials(t *testing.T) {
+	t.Parallel()
+	cfg := baseConfig()
+	cfg.ExplainEnabled = true
+	cfg.Environ = func() []string { return []string{"API_TOKEN=secret-value"} }
+	cfg.Assignments = render.AssignmentTokens{"--password=hunter2"}
+
+	result, err := run(t, cfg)
+
+	require.NoError(t, err)
+	assert.NotContains(t, string(result.Output()), "hunter2")
+	assert.Equal(t,
+		"Password = \"<redacted>\"  # from argument #0 (--password=<redacted>)\n"+
+			"env.API_TOKEN = \"<redacted>\"  # from environment API_TOKEN\n"+
+			"renderizer.profile = \"\"  # from flag --profile\n",
+		string(result.Output()))
+}
@@ -0,0 +1,147 @@
+package render
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/gomatic/renderizer/internal/constants"
+	"github.com/gomatic/renderizer/internal/region"
+	"github.com/gomatic/renderizer/internal/settings"
+	"github.com/gomatic/renderizer/internal/variables"
+)
+
+// Rendering in place: an --in-place file keeps every byte outside its
+// generated regions, and each region is rendered afresh, against the run's
+// data, from the template its begin marker names — resolved relative to the
+// file — or holds inline. In check mode nothing is written; a region that
+// would change fails the run instead, so a pipeline can tell a generated
+// section has drifted from its template. A region's template is rendered as
+// any other is, its front matter's defaults, required keys, missingkey option
+// and delimiters applying, except that its output is the region: front matter
+// sending it to a file fails with ErrRegionOutput.
+
+// inPlace renders the regions of every --in-place file, writing back each
+// file they change or, in check mode, failing with ErrStaleRegion for every
+// region out of date.
+func inPlace(ctx context.Context, cfg Config, data variables.Context) (Result, error) {
+	r, err := newRenderer(cfg, data)
+	if err != nil {
+		return Result{}, err
+	}
+	var outputs []Output
+	var stale []error
+	for _, target := range cfg.InPlace {
+		written, failures, err := r.inPlace(ctx, cfg, data, target)
+		if err != nil {
+			return Result{Outputs: outputs}, err
+		}
+		outputs = append(outputs, written...)
+		stale = append(stale, failures...)
+	}
+	return Result{Outputs: outputs}, errors.Join(stale...)
+}
+
+// inPlace renders the regions of the file target, returning the file as
+// written back when they change it or, in check mode, a failure for each
+// region out of date.
+func (r renderer) inPlace(
+	ctx context.Context,
+	cfg Config,
+	data variables.Context,
+	target string,
+) ([]Output, []error, error) {
+	rewritten, changed, err := r.rewrite(ctx, cfg, data, target)
+	switch {
+	case err != nil:
+		return nil, nil, err
+	case bool(cfg.CheckEnabled):
+		return nil, staleRegions(target, changed), nil
+	case len(changed) == 0:
+		return nil, nil, nil
+	}
+	written, err := write(cfg, Output{Path: target, Content: rewritten})
+	if err != nil {
+		return nil, nil, err
+	}
+	return []Output{written}, nil, nil
+}
+
+// rewrite returns the content of the file target with each of its regions
+// rendered afresh, and the regions whose content that changes.
+func (r renderer) rewrite(
+	ctx context.Context,
+	cfg Config,
+	data variables.Context,
+	target string,
+) ([]byte, []region.Region, error) {
+	content, err := cfg.ReadFile(target)
+	if err != nil {
+		return nil, nil, constants.ErrOpenTemplate.With(err, target)
+	}
+	regions, err := region.Find(content)
+	if err != nil {
+		return nil, nil, attributed(templateSource{name: target}, err)
+	}
+	rendered := make([][]byte, len(regions))
+	var changed []region.Region
+	for i, marked := range regions {
+		output, err := r.renderRegion(ctx, cfg, data, target, marked)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !marked.IsCurrent(content, output) {
+			changed = append(changed, marked)
+		}
+		rendered[i] = output
+	}
+	return region.Replace(content, regions, rendered), changed, nil
+}
+
+// renderRegion renders marked, a region of the file target, from its source.
+func (r renderer) renderRegion(
+	ctx context.Context,
+	cfg Config,
+	data variables.Context,
+	target string,
+	marked region.Region,
+) ([]byte, error) {
+	path, output, err := r.render(ctx, cfg, data, regionSource(target, marked))
+	switch {
+	case err != nil:
+		return nil, shift(err, target, marked.Line-1)
+	case path != "":
+		return nil, constants.ErrRegionOutput.With(nil, fmt.Sprintf("%s:%d", target, marked.Line))
+	}
+	return output, nil
+}
+
+// regionSource returns the source rendering marked, a region of the file
+// target: its inline template, named for the file, or the template file it
+// names, relative to the file.
+func regionSource(target string, marked region.Region) templateSource {
+	if marked.IsInline {
+		return templateSource{name: target, inline: []byte(marked.Template)}
+	}
+	return templateSource{name: settings.RelativeTo(target, marked.Template)}
+}
+
+// shift moves err, when it is a failure located in the template named name,
+// down by lines: an inline template's first line is its begin marker's.
+func shift(err error, name string, lines int) error {
+	var located *constants.TemplateError
+	if errors.As(err, &located) && located.Template == name && located.Line > 0 {
+		located.Line += lines
+	}
+	return err
+}
+
+// staleRegions returns an ErrStaleRegion failure for each of the regions of
+// target out of date.
+func staleRegions(target string, regions []region.Region) []error {
+	failures := make([]error, len(regions))
+	for i, marked := range regions {
+		failures[i] = constants.ErrStaleRegion.With(nil, fmt.Sprintf("%s:%d", target, marked.Line))
+	}
+	return failures
+}
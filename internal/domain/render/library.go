@@ -0,0 +1,105 @@
+package render
+
+import (
+	"io/fs"
+	"path/filepath"
+
+	"github.com/gomatic/renderizer/internal/constants"
+	"github.com/gomatic/renderizer/internal/settings"
+	"github.com/gomatic/renderizer/internal/template"
+	"github.com/gomatic/renderizer/internal/variables"
+)
+
+// The template library: partials shared by every template of a run, named by
+// the settings `lib` key and --lib, each a directory of files, a glob, or a
+// file. It is read and parsed once per run, however many templates render
+// with it.
+
+// loadLibrary reads and parses the run's library: the files the settings name,
+// then those --lib names, so a definition given on the command line overrides
+// one from settings. A run naming no library has the empty one.
+func loadLibrary(cfg Config, data variables.Context, funcs map[string]any) (template.Library, error) {
+	paths, err := libraryPaths(cfg, data)
+	if err != nil {
+		return template.Library{}, err
+	}
+	var files []template.LibraryFile
+	for _, path := range paths {
+		found, err := libraryFiles(cfg, path)
+		if err != nil {
+			return template.Library{}, err
+		}
+		files = append(files, found...)
+	}
+	return template.ParseLibrary(funcs, templateDelims(cfg), files)
+}
+
+// libraryPaths returns the library paths the settings name, already resolved
+// against the file naming them, followed by the --lib paths.
+func libraryPaths(cfg Config, data variables.Context) ([]string, error) {
+	var paths []string
+	switch library := data[settings.LibraryKey].(type) {
+	case nil:
+	case string:
+		paths = append(paths, library)
+	case []any:
+		for _, entry := range library {
+			path, isPath := entry.(string)
+			if !isPath {
+				return nil, constants.ErrReadLibrary.With(nil, settings.LibraryKey, "names a non-path", entry)
+			}
+			paths = append(paths, path)
+		}
+	default:
+		return nil, constants.ErrReadLibrary.With(nil, settings.LibraryKey, "names a non-path", library)
+	}
+	return append(paths, cfg.Library...), nil
+}
+
+// libraryFiles reads the files path names: those in it when it is a
+// directory, and those it matches otherwise.
+func libraryFiles(cfg Config, path string) ([]template.LibraryFile, error) {
+	names, err := libraryMatches(cfg, path)
+	if err != nil {
+		return nil, err
+	}
+	files := make([]template.LibraryFile, 0, len(names))
+	for _, name := range names {
+		source, err := cfg.ReadFile(name)
+		if err != nil {
+			return nil, constants.ErrReadLibrary.With(err, name)
+		}
+		files = append(files, template.LibraryFile{Name: template.Name(name), Source: source})
+	}
+	return files, nil
+}
+
+// libraryMatches returns the names of the files directly in the directory
+// path, or else those the glob path matches, in lexical order. A path
+// matching nothing fails with the reason it is not a directory.
+func libraryMatches(cfg Config, path string) ([]string, error) {
+	entries, err := cfg.ReadDir(path)
+	if err == nil {
+		return directoryFiles(path, entries), nil
+	}
+	matches, globErr := cfg.Glob(path)
+	switch {
+	case globErr != nil:
+		return nil, constants.ErrReadLibrary.With(globErr, path)
+	case len(matches) == 0:
+		return nil, constants.ErrReadLibrary.With(err, path)
+	}
+	return matches, nil
+}
+
+// directoryFiles returns the names of the entries of the directory dir that
+// are not directories themselves.
+func directoryFiles(dir string, entries []fs.DirEntry) []string {
+	var names []string
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			names = append(names, filepath.Join(dir, entry.Name()))
+		}
+	}
+	return names
+}
@@ -0,0 +1,62 @@
+package render
+
+import (
+	"bytes"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/gomatic/renderizer/internal/constants"
+)
+
+// Splitting one template's output into several files: a line of the form
+// `---# file: PATH` in the rendered output starts the file at PATH, which runs
+// to the next such line or the end. Being part of the output, the path is
+// rendered like the rest, so `---# file: deploy/{{ .Name }}.yaml` names a
+// file per value a range yields. The marker lines themselves are dropped.
+// Being rendered, the path is as untrusted as the template: a run writes no
+// file outside the working directory, and a sandboxed run writes none at all.
+
+// fileMarker matches a file marker line, capturing its path.
+var fileMarker = regexp.MustCompile(`(?m)^---# file:[ \t]*(.*?)\r?$\n?`)
+
+// split returns the outputs rendered holds: the content after each file
+// marker, for the file the marker names, preceded by the content before the
+// first marker, for path, unless the markers leave it blank. A marker naming
+// no file starts more of the run's output.
+func split(path string, rendered []byte) []Output {
+	markers := fileMarker.FindAllSubmatchIndex(rendered, -1)
+	if len(markers) == 0 {
+		return []Output{{Path: path, Content: rendered}}
+	}
+	var outputs []Output
+	if lead := rendered[:markers[0][0]]; len(bytes.TrimSpace(lead)) > 0 {
+		outputs = append(outputs, Output{Path: path, Content: lead})
+	}
+	for i, marker := range markers {
+		end := len(rendered)
+		if i+1 < len(markers) {
+			end = markers[i+1][0]
+		}
+		outputs = append(outputs, Output{
+			Path:    strings.TrimSpace(string(rendered[marker[2]:marker[3]])),
+			Content: rendered[marker[1]:end],
+		})
+	}
+	return outputs
+}
+
+// confine fails when the run may not write path, a file a template's output
+// names: under --sandbox, or when it is absolute or cleans to a path outside
+// the working directory. An empty path is the run's output, which it may.
+func confine(cfg Config, path string) error {
+	switch {
+	case path == "":
+		return nil
+	case bool(cfg.SandboxEnabled):
+		return constants.ErrSandboxedOutput.With(nil, path)
+	case !filepath.IsLocal(path):
+		return constants.ErrOutputPath.With(nil, path)
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package render
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/gomatic/renderizer/internal/environment"
+	"github.com/gomatic/renderizer/internal/variables"
+)
+
+// Redacting the data context for diagnostic output: the debug dump and
+// --explain print values to a terminal or a CI log, where a credential must
+// not appear. Rendering never sees the redacted copy, so a template may still
+// use any variable --env-allow and --env-deny expose.
+
+// redactedValue replaces a likely credential in diagnostic output.
+const redactedValue = "<redacted>"
+
+// redact returns a copy of data with the value of every key that looks like a
+// credential — at any depth, the environment map included — replaced.
+func redact(data variables.Context) variables.Context {
+	return redactMap(data)
+}
+
+// redactMap copies one map level, redacting sensitive keys.
+func redactMap(values map[string]any) map[string]any {
+	redacted := make(map[string]any, len(values))
+	for key, value := range values {
+		if environment.IsSensitive(key) {
+			redacted[key] = redactedValue
+			continue
+		}
+		redacted[key] = redactValue(value)
+	}
+	return redacted
+}
+
+// redactValue redacts within a value, descending maps and lists.
+func redactValue(value any) any {
+	switch nested := value.(type) {
+	case map[string]any:
+		return redactMap(nested)
+	case map[string]string:
+		return redactStrings(nested)
+	case []any:
+		redacted := make([]any, len(nested))
+		for i, element := range nested {
+			redacted[i] = redactValue(element)
+		}
+		return redacted
+	}
+	return value
+}
+
+// redactStrings copies a string map — the environment binding — redacting
+// sensitive names.
+func redactStrings(values map[string]string) map[string]string {
+	redacted := make(map[string]string, len(values))
+	for name, text := range values {
+		if environment.IsSensitive(name) {
+			text = redactedValue
+		}
+		redacted[name] = text
+	}
+	return redacted
+}
+
+// redactToken returns an assignment token for display, its value redacted when
+// any name on the path it sets looks like a credential.
+func redactToken(token string, names []string) string {
+	if !slices.ContainsFunc(names, environment.IsSensitive) {
+		return token
+	}
+	if name, _, isSet := strings.Cut(token, "="); isSet {
+		return name + "=" + redactedValue
+	}
+	return token
+}
@@ -1,14 +1,20 @@
 package render
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"io"
 	"log/slog"
+	"slices"
+	"strings"
 
 	"gopkg.in/yaml.v3"
 
 	"github.com/gomatic/renderizer/internal/constants"
 	"github.com/gomatic/renderizer/internal/domain"
+	"github.com/gomatic/renderizer/internal/format"
+	"github.com/gomatic/renderizer/internal/frontmatter"
 	"github.com/gomatic/renderizer/internal/template"
 	"github.com/gomatic/renderizer/internal/variables"
 )
@@ -16,26 +22,58 @@ import (
 // defaultBase is the fallback base name for default settings/template discovery.
 const defaultBase = "renderizer"
 
-// Result is the outcome of a render: the concatenated rendered output, ready to
-// be written verbatim to the command's writer.
+// Output is one named output of a render: the path of the file it was written
+// to, or empty for the run's output, and its content.
+type Output struct {
+	Path    string
+	Content []byte
+}
+
+// Result is the outcome of a render: every output, in the order it was
+// rendered. Those with a path have been written to their files; the rest make
+// up the run's output.
 type Result struct {
-	Output []byte
+	Outputs []Output
+}
+
+// Output returns the run's output, the concatenated content of the outputs
+// without a path, ready to be written verbatim to the command's writer.
+func (r Result) Output() []byte {
+	var output []byte
+	for _, named := range r.Outputs {
+		if named.Path == "" {
+			output = append(output, named.Content...)
+		}
+	}
+	return output
 }
 
 // Run builds the template data context, resolves the templates to render, and
-// renders each, returning the concatenated output. It holds no presentation
+// renders each, returning its outputs. With explain enabled it instead returns
+// the context annotated with each value's origin, rendering nothing, and with
+// --in-place files it renders their regions instead. It holds no presentation
 // logic; the caller writes Result.Output.
-func Run(_ context.Context, logger *slog.Logger, cfg Config, _ ...domain.Argument) (Result, error) {
+func Run(ctx context.Context, logger *slog.Logger, cfg Config, _ ...domain.Argument) (Result, error) {
 	data, err := buildContext(cfg)
 	if err != nil {
 		return Result{}, err
 	}
+	if bool(cfg.ExplainEnabled) {
+		return explain(cfg, data)
+	}
+	if len(cfg.InPlace) > 0 {
+		result, err := inPlace(ctx, cfg, data)
+		logWritten(logger, cfg, result)
+		return result, err
+	}
 	sources, err := resolveSources(cfg)
 	if err != nil {
 		return Result{}, err
 	}
 	logResolution(logger, cfg, data, sources)
-	return renderAll(cfg, data, sources)
+	result, err := renderAll(ctx, cfg, data, sources)
+	logWritten(logger, cfg, result)
+	return result, err
 }
 
 // logResolution emits the verbose template/source summary and the debug context
@@ -47,10 +85,36 @@ func logResolution(logger *slog.Logger, cfg Config, data variables.Context, sour
 		logger.Info("Resolved templates.", "templates", sourceNames(sources))
 	}
 	if bool(cfg.DebuggingEnabled) {
+		logger.Debug("Settings files.", "found", foundSettings(cfg))
 		logger.Debug("Template context.", "data", dump(data))
 	}
 }
 
+// logWritten emits the verbose summary of the files the render wrote.
+func logWritten(logger *slog.Logger, cfg Config, result Result) {
+	var written []string
+	for _, named := range result.Outputs {
+		if named.Path != "" {
+			written = append(written, named.Path)
+		}
+	}
+	if bool(cfg.VerboseEnabled) && len(written) > 0 {
+		logger.Info("Wrote output files.", "files", written)
+	}
+}
+
+// foundSettings lists the optional settings files — discovered and overlays —
+// that exist, for debug logging.
+func foundSettings(cfg Config) []string {
+	var found []string
+	for _, file := range settingsFiles(cfg) {
+		if file.IsOptional && cfg.Exists(file.Path) {
+			found = append(found, file.Path)
+		}
+	}
+	return found
+}
+
 // sourceNames lists the resolved source names for logging.
 func sourceNames(sources []templateSource) []string {
 	names := make([]string, len(sources))
@@ -60,46 +124,265 @@ func sourceNames(sources []templateSource) []string {
 	return names
 }
 
-// dump renders the context as YAML for debug logging. Marshaling a plain
-// map/slice/scalar tree is infallible.
+// dump renders the context as YAML for debug logging, with likely
+// credentials redacted. Marshaling a plain map/slice/scalar tree is
+// infallible.
 func dump(data variables.Context) string {
-	out, _ := yaml.Marshal(map[string]any(data))
+	out, _ := yaml.Marshal(map[string]any(redact(data)))
 	return string(out)
 }
 
-// renderAll renders every source against data and concatenates the output,
-// terminating each rendered block with a newline as the historical tool did.
-func renderAll(cfg Config, data variables.Context, sources []templateSource) (Result, error) {
-	funcs := template.Funcs(template.TestingEnabled(cfg.TestingEnabled))
-	missing := template.NormalizeMissingKey(template.MissingKey(cfg.MissingKey))
-	var output []byte
+// renderAll renders every source against data, alongside the library parsed
+// once for them all, into its outputs: the run's output, terminating each
+// rendered block with a newline as the historical tool did, and the files its
+// front matter and file markers send it to, which are written as they are
+// rendered.
+// It stops at the first failure unless keep-going is enabled, when it renders
+// the rest and returns every failure, attributed to its source, joined.
+func renderAll(ctx context.Context, cfg Config, data variables.Context, sources []templateSource) (Result, error) {
+	r, err := newRenderer(cfg, data)
+	if err != nil {
+		return Result{}, err
+	}
+	var outputs []Output
+	var failures []error
 	for _, source := range sources {
-		rendered, err := renderOne(cfg, funcs, missing, data, source)
+		if outputs, err = r.emit(ctx, cfg, data, source, outputs); err == nil {
+			continue
+		}
+		if !bool(cfg.KeepGoingEnabled) {
+			return Result{Outputs: outputs}, err
+		}
+		failures = append(failures, attributed(source, err))
+	}
+	return Result{Outputs: outputs}, errors.Join(failures...)
+}
+
+// attributed returns err as a failure of source: unchanged when it already
+// locates itself in a template, and otherwise carrying the source's name.
+func attributed(source templateSource, err error) error {
+	var located *constants.TemplateError
+	if errors.As(err, &located) {
+		return err
+	}
+	return &constants.TemplateError{Cause: err, Template: source.name}
+}
+
+// renderer renders the sources of a run with its functions, library, and
+// missingkey option.
+type renderer struct {
+	funcs   map[string]any
+	library template.Library
+	missing template.MissingKey
+}
+
+// newRenderer returns the renderer of this run's sources, loading its library.
+func newRenderer(cfg Config, data variables.Context) (renderer, error) {
+	funcs := templateFuncs(cfg)
+	library, err := loadLibrary(cfg, data, funcs)
+	if err != nil {
+		return renderer{}, err
+	}
+	return renderer{
+		funcs:   funcs,
+		library: library,
+		missing: template.NormalizeMissingKey(template.MissingKey(cfg.MissingKey)),
+	}, nil
+}
+
+// emit renders source into the outputs its file markers split it into,
+// writing each to its file, and appends them to outputs.
+func (r renderer) emit(
+	ctx context.Context,
+	cfg Config,
+	data variables.Context,
+	source templateSource,
+	outputs []Output,
+) ([]Output, error) {
+	path, rendered, err := r.render(ctx, cfg, data, source)
+	if err != nil {
+		return outputs, err
+	}
+	for _, named := range split(path, rendered) {
+		written, err := deliver(cfg, source, named)
 		if err != nil {
-			return Result{Output: output}, err
+			return outputs, err
 		}
-		output = append(append(output, rendered...), '\n')
+		outputs = append(outputs, written)
+	}
+	return outputs, nil
+}
+
+// deliver finishes named and writes it, when the run may write the file the
+// template names for it.
+func deliver(cfg Config, source templateSource, named Output) (Output, error) {
+	if err := confine(cfg, named.Path); err != nil {
+		return named, err
+	}
+	finished, err := finish(cfg, source, named)
+	if err != nil {
+		return finished, err
+	}
+	return write(cfg, finished)
+}
+
+// finish formats named, or only validates it, as the type of file it is, when
+// --format-output or --validate-output ask to. The run's output is the type
+// its template's name says.
+func finish(cfg Config, source templateSource, named Output) (Output, error) {
+	name := named.Path
+	if name == "" {
+		name = source.name
+	}
+	switch {
+	case bool(cfg.FormatOutputEnabled):
+		formatted, err := format.Format(name, named.Content)
+		named.Content = unterminated(named, formatted)
+		return named, err
+	case bool(cfg.ValidateOutputEnabled):
+		return named, format.Validate(name, named.Content)
+	}
+	return named, nil
+}
+
+// unterminated returns formatted, the canonical form of named, without the
+// newline ending it when named is for the run's output, which write ends with
+// one: the run writes a formatted output exactly as the formatter prints it.
+func unterminated(named Output, formatted []byte) []byte {
+	if named.Path != "" {
+		return formatted
+	}
+	return bytes.TrimSuffix(formatted, []byte("\n"))
+}
+
+// write writes named to its file, or, when it is for the run's output,
+// terminates it with a newline.
+func write(cfg Config, named Output) (Output, error) {
+	if named.Path == "" {
+		named.Content = slices.Concat(named.Content, []byte("\n"))
+		return named, nil
+	}
+	if err := cfg.WriteFile(named.Path, named.Content); err != nil {
+		return named, constants.ErrWriteOutput.With(err, named.Path)
 	}
-	return Result{Output: output}, nil
+	return named, nil
 }
 
-// renderOne reads and renders a single source.
-func renderOne(
+// render reads a single source and renders its body as its front matter
+// describes: against data over the front matter's defaults, once they hold
+// its required keys, in its syntax and missingkey option, within the
+// configured limits. It returns the path of the file the front matter sends
+// the output to, or empty, with the output.
+func (r renderer) render(
+	ctx context.Context,
 	cfg Config,
-	funcs map[string]any,
-	missing template.MissingKey,
 	data variables.Context,
 	source templateSource,
-) ([]byte, error) {
+) (string, []byte, error) {
+	matter, body, err := readMatter(cfg, source)
+	if err != nil {
+		return "", nil, err
+	}
+	data = withDefaults(data, matter.Defaults)
+	if err := matter.Check(data); err != nil {
+		return "", nil, err
+	}
+	syntax, err := matterSyntax(templateSyntax(cfg, r.library, source), matter)
+	if err != nil {
+		return "", nil, err
+	}
+	missing := matterMissing(r.missing, matter)
+	output, err := template.RenderLimited(ctx, renderLimits(cfg), r.funcs, missing, syntax,
+		template.Name(source.name), body, map[string]any(data))
+	if err != nil {
+		return "", nil, matter.Shift(source.name, err)
+	}
+	path, err := r.outputPath(missing, syntax, source, matter, data)
+	if err != nil {
+		return "", nil, err
+	}
+	return path, output, confine(cfg, path)
+}
+
+// readMatter reads a source and splits its front matter from its body.
+func readMatter(cfg Config, source templateSource) (frontmatter.Matter, []byte, error) {
 	bytes, err := read(cfg, source)
 	if err != nil {
-		return nil, err
+		return frontmatter.Matter{}, nil, err
+	}
+	return frontmatter.Split(bytes)
+}
+
+// matterSyntax returns syntax with the action delimiters matter declares, in
+// place of the --delims ones, if it declares any.
+func matterSyntax(syntax template.Syntax, matter frontmatter.Matter) (template.Syntax, error) {
+	if matter.Delims == "" {
+		return syntax, nil
+	}
+	delims, err := template.ParseDelims(string(matter.Delims))
+	if err != nil {
+		return template.Syntax{}, err
+	}
+	syntax.Delims = delims
+	return syntax, nil
+}
+
+// matterMissing returns the missingkey option matter declares, in place of
+// the --missing one, if it declares one.
+func matterMissing(missing template.MissingKey, matter frontmatter.Matter) template.MissingKey {
+	if matter.Missing == "" {
+		return missing
+	}
+	return template.NormalizeMissingKey(template.MissingKey(matter.Missing))
+}
+
+// outputPath renders the output path matter declares against data, in
+// syntax, or returns empty when it declares none. A path rendering empty sends
+// the output to the run's output, so a template can choose whether to write a
+// file. The path is confined as a file marker's is, whether or not the output
+// it heads is written.
+func (r renderer) outputPath(
+	missing template.MissingKey,
+	syntax template.Syntax,
+	source templateSource,
+	matter frontmatter.Matter,
+	data variables.Context,
+) (string, error) {
+	if matter.Output == "" {
+		return "", nil
 	}
-	return template.Render(funcs, missing, template.Name(source.name), bytes, map[string]any(data))
+	path, err := template.Render(r.funcs, missing, template.Syntax{Delims: syntax.Delims},
+		template.Name(source.name+":output"), []byte(matter.Output), map[string]any(data))
+	return strings.TrimSpace(string(path)), err
 }
 
-// read returns the bytes of a source: stdin or a file via the injected reader.
+// htmlSuffix marks a template --html-auto parses as HTML.
+const htmlSuffix = ".html.tmpl"
+
+// templateSyntax returns how source is parsed: alongside library, with the
+// --delims delimiters, and as HTML under --html, or under --html-auto when it
+// is named *.html.tmpl.
+func templateSyntax(cfg Config, library template.Library, source templateSource) template.Syntax {
+	isHTML := bool(cfg.HTMLEnabled) ||
+		bool(cfg.HTMLAutoEnabled) && !source.isStdin && strings.HasSuffix(source.name, htmlSuffix)
+	return template.Syntax{Library: library, Delims: templateDelims(cfg), IsHTML: template.HTMLEnabled(isHTML)}
+}
+
+// renderLimits returns the limits bounding each render.
+func renderLimits(cfg Config) template.Limits {
+	return template.Limits{
+		Timeout:       template.Timeout(cfg.Timeout),
+		MaxOutput:     template.OutputBytes(cfg.MaxOutput),
+		MaxIterations: template.Iterations(cfg.MaxIterations),
+	}
+}
+
+// read returns the bytes of a source: an inline template, stdin, or a file via
+// the injected reader.
 func read(cfg Config, source templateSource) ([]byte, error) {
+	if source.inline != nil {
+		return source.inline, nil
+	}
 	if source.isStdin {
 		data, err := io.ReadAll(cfg.Source)
 		if err != nil {
@@ -4,10 +4,12 @@ import (
 	"context"
 	"errors"
 	"io"
+	"io/fs"
 	"log/slog"
 	"os"
 	"strings"
 	"testing"
+	"testing/fstest"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -76,7 +78,7 @@ func TestRunStdin(t *testing.T) {
 
 	result, err := run(t, cfg)
 	require.NoError(t, err)
-	assert.Equal(t, "Hello, World!\n", string(result.Output))
+	assert.Equal(t, "Hello, World!\n", string(result.Output()))
 }
 
 func TestRunVerboseAndDebugLogging(t *testing.T) {
@@ -90,7 +92,24 @@ func TestRunVerboseAndDebugLogging(t *testing.T) {
 
 	result, err := run(t, cfg)
 	require.NoError(t, err)
-	assert.Equal(t, "X\n", string(result.Output))
+	assert.Equal(t, "X\n", string(result.Output()))
+}
+
+func TestRunDebugLogsFoundOverlays(t *testing.T) {
+	t.Parallel()
+	var logs strings.Builder
+	cfg := baseConfig()
+	cfg.DebuggingEnabled = true
+	cfg.Profile = "prod"
+	cfg.Templates = render.TemplateFiles{"app.tmpl"}
+	cfg.ReadFile = mapReadFile(map[string]string{"app.tmpl": "ok"})
+	cfg.Exists = existsIn(".app.prod.yaml")
+
+	_, err := render.Run(context.Background(),
+		slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug})), cfg)
+
+	require.NoError(t, err)
+	assert.Contains(t, logs.String(), "found=[.app.prod.yaml]")
 }
 
 func TestRunTemplateFile(t *testing.T) {
@@ -102,7 +121,7 @@ func TestRunTemplateFile(t *testing.T) {
 
 	result, err := run(t, cfg)
 	require.NoError(t, err)
-	assert.Equal(t, "Hi Bob\n", string(result.Output))
+	assert.Equal(t, "Hi Bob\n", string(result.Output()))
 }
 
 func TestRunStdinReadError(t *testing.T) {
@@ -155,5 +174,472 @@ func TestRunRenderErrorReturnsPartialOutput(t *testing.T) {
 
 	result, err := run(t, cfg)
 	require.ErrorIs(t, err, constants.ErrParseTemplate)
-	assert.Equal(t, "good\n", string(result.Output))
+	assert.Equal(t, "good\n", string(result.Output()))
+}
+
+// TestRunKeepGoingRendersEveryTemplate pins that keep-going renders past a
+// failure, keeps every successful output in order, and joins each failure
+// attributed to the template it occurred in.
+func TestRunKeepGoingRendersEveryTemplate(t *testing.T) {
+	t.Parallel()
+	cfg := baseConfig()
+	cfg.KeepGoingEnabled = true
+	cfg.Templates = render.TemplateFiles{"a.tmpl", "bad.tmpl", "gone.tmpl", "b.tmpl"}
+	cfg.ReadFile = mapReadFile(map[string]string{
+		"a.tmpl":   "first",
+		"bad.tmpl": "{{.Unclosed",
+		"b.tmpl":   "second",
+	})
+
+	result, err := run(t, cfg)
+	assert.Equal(t, "first\nsecond\n", string(result.Output()))
+	require.ErrorIs(t, err, constants.ErrParseTemplate)
+	require.ErrorIs(t, err, constants.ErrOpenTemplate)
+
+	var joined interface{ Unwrap() []error }
+	require.ErrorAs(t, err, &joined)
+	var names []string
+	for _, failure := range joined.Unwrap() {
+		var located *constants.TemplateError
+		require.ErrorAs(t, failure, &located)
+		names = append(names, located.Template)
+	}
+	assert.Equal(t, []string{"bad.tmpl", "gone.tmpl"}, names)
+}
+
+// TestRunRedactsCredentialsOnlyInDiagnostics pins the compliance contract of
+// the debug dump: a variable named like a credential is redacted in the log,
+// at any depth, yet the template still renders its real value.
+func TestRunRedactsCredentialsOnlyInDiagnostics(t *testing.T) {
+	t.Parallel()
+	var logs strings.Builder
+	cfg := baseConfig()
+	cfg.DebuggingEnabled = true
+	cfg.Environ = func() []string { return []string{"GITHUB_TOKEN=ghp_live", "USER=alice"} }
+	cfg.Templates = render.TemplateFiles{"t.tmpl"}
+	cfg.ReadFile = mapReadFile(map[string]string{"t.tmpl": "{{.env.GITHUB_TOKEN}} {{.Db.Password}}"})
+	cfg.Assignments = render.AssignmentTokens{"--db.password=hunter2", "--db.host=x"}
+
+	result, err := render.Run(context.Background(),
+		slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug})), cfg)
+
+	require.NoError(t, err)
+	assert.Equal(t, "ghp_live hunter2\n", string(result.Output()))
+	assert.NotContains(t, logs.String(), "ghp_live")
+	assert.NotContains(t, logs.String(), "hunter2")
+	assert.Contains(t, logs.String(), "<redacted>")
+	assert.Contains(t, logs.String(), "alice")
+}
+
+// TestRunSandboxHidesTheEnvironment pins the sandbox's two halves: the
+// environment map is not bound, and a side-effecting function fails to parse.
+func TestRunSandboxHidesTheEnvironment(t *testing.T) {
+	t.Parallel()
+	cfg := baseConfig()
+	cfg.SandboxEnabled = true
+	cfg.MissingKey = "zero"
+	cfg.Templates = render.TemplateFiles{"t.tmpl"}
+	cfg.ReadFile = mapReadFile(map[string]string{"t.tmpl": "[{{ .env }}]"})
+
+	result, err := run(t, cfg)
+	require.NoError(t, err)
+	assert.Equal(t, "[<no value>]\n", string(result.Output()))
+
+	cfg.ReadFile = mapReadFile(map[string]string{"t.tmpl": `{{ env "HOME" }}`})
+	_, err = run(t, cfg)
+	require.ErrorIs(t, err, constants.ErrRemovedFunction)
+
+	cfg.AllowedFuncs = render.FuncNames{"env"}
+	result, err = run(t, cfg)
+	require.NoError(t, err, "an allowed function is restored to the sandbox")
+	assert.NotEmpty(t, result.Output())
+}
+
+// TestRunLimitsEachTemplate pins that the configured limits bound every
+// template a run renders, each on its own count.
+func TestRunLimitsEachTemplate(t *testing.T) {
+	t.Parallel()
+	cfg := baseConfig()
+	cfg.MaxIterations = 3
+	cfg.MaxOutput = 3
+	cfg.Templates = render.TemplateFiles{"a.tmpl", "b.tmpl"}
+	cfg.ReadFile = mapReadFile(map[string]string{
+		"a.tmpl": "{{ range 3 }}a{{ end }}",
+		"b.tmpl": "{{ range 3 }}b{{ end }}",
+	})
+
+	result, err := run(t, cfg)
+	require.NoError(t, err)
+	assert.Equal(t, "aaa\nbbb\n", string(result.Output()))
+
+	cfg.ReadFile = mapReadFile(map[string]string{"a.tmpl": "{{ range 4 }}{{ end }}"})
+	_, err = run(t, cfg)
+	require.ErrorIs(t, err, constants.ErrIterationLimit)
+}
+
+// TestRunDelims pins that --delims applies to templates and, when they are
+// templated, to settings files alike.
+func TestRunDelims(t *testing.T) {
+	t.Parallel()
+	cfg := baseConfig()
+	cfg.Delims = "[[,]]"
+	cfg.TemplateSettingsEnabled = true
+	cfg.Settings = render.SettingsFiles{"s.yaml"}
+	cfg.Templates = render.TemplateFiles{"t.tmpl"}
+	cfg.Assignments = render.AssignmentTokens{"--name=ci"}
+	cfg.ReadFile = mapReadFile(map[string]string{
+		"s.yaml": "Job: '[[ .Name ]]-build'",
+		"t.tmpl": "[[ .Job ]]: ${{ matrix.os }}",
+	})
+
+	result, err := run(t, cfg)
+	require.NoError(t, err)
+	assert.Equal(t, "ci-build: ${{ matrix.os }}\n", string(result.Output()))
+
+	cfg.Delims = "[["
+	_, err = run(t, cfg)
+	require.ErrorIs(t, err, constants.ErrParseDelims)
+}
+
+// TestRunHTMLAutoEscapesOnlyHTMLTemplates pins --html-auto: a template named
+// *.html.tmpl is escaped, and any other renders as text.
+func TestRunHTMLAutoEscapesOnlyHTMLTemplates(t *testing.T) {
+	t.Parallel()
+	cfg := baseConfig()
+	cfg.HTMLAutoEnabled = true
+	cfg.Templates = render.TemplateFiles{"page.html.tmpl", "page.txt.tmpl"}
+	cfg.Assignments = render.AssignmentTokens{"--name=<b>"}
+	cfg.ReadFile = mapReadFile(map[string]string{
+		"page.html.tmpl": "<p>{{ .Name }}</p>",
+		"page.txt.tmpl":  "{{ .Name }}",
+	})
+
+	result, err := run(t, cfg)
+	require.NoError(t, err)
+	assert.Equal(t, "<p>&lt;b&gt;</p>\n<b>\n", string(result.Output()))
+
+	cfg.HTMLAutoEnabled, cfg.HTMLEnabled = false, true
+	result, err = run(t, cfg)
+	require.NoError(t, err)
+	assert.Equal(t, "<p>&lt;b&gt;</p>\n&lt;b&gt;\n", string(result.Output()))
+}
+
+// TestRunLibrary pins the library sources: a directory, then a glob, each
+// named in settings or by --lib, whose definitions every template can use,
+// with those --lib names overriding the settings' ones.
+func TestRunLibrary(t *testing.T) {
+	t.Parallel()
+	files := fstest.MapFS{
+		"conf/s.yaml":               {Data: []byte("lib: partials")},
+		"conf/partials/labels.tmpl": {Data: []byte(`{{ define "labels" }}app=x{{ end }}{{ define "name" }}base{{ end }}`)},
+		"override/name.tpl":         {Data: []byte(`{{ define "name" }}override{{ end }}`)},
+		"a.tmpl":                    {Data: []byte(`{{ template "labels" }}`)},
+		"b.tmpl":                    {Data: []byte(`{{ template "name" }}`)},
+	}
+	cfg := baseConfig()
+	cfg.Settings = render.SettingsFiles{"conf/s.yaml"}
+	cfg.Library = render.LibraryPaths{"override/*.tpl"}
+	cfg.Templates = render.TemplateFiles{"a.tmpl", "b.tmpl"}
+	cfg.ReadFile = func(name string) ([]byte, error) { return fs.ReadFile(files, name) }
+	cfg.ReadDir = func(name string) ([]fs.DirEntry, error) { return fs.ReadDir(files, name) }
+	cfg.Glob = func(pattern string) ([]string, error) { return fs.Glob(files, pattern) }
+
+	result, err := run(t, cfg)
+	require.NoError(t, err)
+	assert.Equal(t, "app=x\noverride\n", string(result.Output()))
+
+	cfg.Library = render.LibraryPaths{"missing"}
+	_, err = run(t, cfg)
+	require.ErrorIs(t, err, constants.ErrReadLibrary)
+	require.ErrorIs(t, err, fs.ErrNotExist)
+}
+
+// TestRunFrontMatter pins a template's front matter: its defaults fill only
+// the names settings and the command line leave unset, for that template
+// alone; its required keys must be set; its delimiters and missingkey option
+// replace the run's; its output path, rendered against the data, receives the
+// output in place of the run's output; and a failure in its body is reported
+// on the line it is on in the source.
+func TestRunFrontMatter(t *testing.T) {
+	t.Parallel()
+	files := map[string]string{
+		"s.yaml": "Image:\n  Tag: stable\n",
+		"deploy.tmpl": "---\ndefaults:\n  Replicas: 1\n  Image:\n    Name: nginx\n    Tag: latest\n" +
+			"required: [Name]\noutput: out/[[ .Name ]].yaml\ndelims: '[[,]]'\n---\n" +
+			"[[ .Name ]]: [[ .Image.Name ]]:[[ .Image.Tag ]] x[[ .Replicas ]]",
+		"plain.tmpl":  "{{ .Replicas }}",
+		"broken.tmpl": "---\nmissingkey: zero\n---\n{{ .Unset }}\n{{ .Name | nosuch }}",
+	}
+	written := map[string]string{}
+	cfg := baseConfig()
+	cfg.ReadFile = mapReadFile(files)
+	cfg.WriteFile = func(name string, data []byte) error {
+		written[name] = string(data)
+		return nil
+	}
+	cfg.Settings = render.SettingsFiles{"s.yaml"}
+	cfg.Assignments = render.AssignmentTokens{"--name=web", "--replicas=3"}
+	cfg.Templates = render.TemplateFiles{"deploy.tmpl", "plain.tmpl"}
+
+	result, err := run(t, cfg)
+	require.NoError(t, err)
+	assert.Equal(t, "3\n", string(result.Output()))
+	assert.Equal(t, map[string]string{"out/web.yaml": "web: nginx:stable x3"}, written)
+
+	cfg.Assignments = nil
+	cfg.Templates = render.TemplateFiles{"deploy.tmpl"}
+	_, err = run(t, cfg)
+	require.ErrorIs(t, err, constants.ErrRequiredKey)
+	assert.Contains(t, err.Error(), "Name")
+
+	cfg.Assignments = render.AssignmentTokens{"--name=web"}
+	cfg.Templates = render.TemplateFiles{"broken.tmpl"}
+	_, err = run(t, cfg)
+	require.ErrorIs(t, err, constants.ErrParseTemplate)
+	var located *constants.TemplateError
+	require.ErrorAs(t, err, &located)
+	assert.Equal(t, 5, located.Line, "the body's second line is the source's fifth")
+}
+
+// TestRunFrontMatterOutputStaysInTheWorkingDirectory pins that front matter
+// cannot send a template's output outside the working directory, nor, under
+// --sandbox, to any file.
+func TestRunFrontMatterOutputStaysInTheWorkingDirectory(t *testing.T) {
+	t.Parallel()
+	written := map[string]string{}
+	cfg := baseConfig()
+	cfg.WriteFile = func(name string, data []byte) error {
+		written[name] = string(data)
+		return nil
+	}
+	cfg.Templates = render.TemplateFiles{"t.tmpl"}
+	cfg.Assignments = render.AssignmentTokens{"--name=web"}
+	for _, output := range []string{"../x", "/tmp/x", "out/../../{{ .Name }}"} {
+		cfg.ReadFile = mapReadFile(map[string]string{"t.tmpl": "---\noutput: " + output + "\n---\n"})
+		_, err := run(t, cfg)
+		require.ErrorIs(t, err, constants.ErrOutputPath, output)
+	}
+
+	cfg.SandboxEnabled = true
+	cfg.ReadFile = mapReadFile(map[string]string{"t.tmpl": "---\noutput: out/x\n---\nx"})
+	_, err := run(t, cfg)
+	require.ErrorIs(t, err, constants.ErrSandboxedOutput)
+	assert.Empty(t, written)
+}
+
+// TestRunFileMarkers pins one template rendering several files: each file
+// marker line starts the file it names, itself rendered, and is dropped; the
+// content before the first marker stays in the run's output unless it is
+// blank; and Result lists every output in the order it was rendered.
+func TestRunFileMarkers(t *testing.T) {
+	t.Parallel()
+	written := map[string]string{}
+	cfg := baseConfig()
+	cfg.StdinEnabled = true
+	cfg.Source = strings.NewReader("{{ range .Apps }}---# file: deploy/{{ . }}.yaml\nname: {{ . }}\n{{ end }}")
+	cfg.Assignments = render.AssignmentTokens{"--apps=web", "--apps=db"}
+	cfg.WriteFile = func(name string, data []byte) error {
+		written[name] = string(data)
+		return nil
+	}
+
+	result, err := run(t, cfg)
+	require.NoError(t, err)
+	assert.Equal(t, []render.Output{
+		{Path: "deploy/web.yaml", Content: []byte("name: web\n")},
+		{Path: "deploy/db.yaml", Content: []byte("name: db\n")},
+	}, result.Outputs)
+	assert.Equal(t, map[string]string{"deploy/web.yaml": "name: web\n", "deploy/db.yaml": "name: db\n"}, written)
+	assert.Empty(t, result.Output())
+
+	cfg.Source = strings.NewReader("index\n---# file: a.txt\na\n---# file:\nrest")
+	result, err = run(t, cfg)
+	require.NoError(t, err)
+	assert.Equal(t, "index\n\nrest\n", string(result.Output()))
+	assert.Equal(t, "a\n", written["a.txt"])
+
+	cfg.Source = strings.NewReader("---# file: a.txt\na")
+	cfg.WriteFile = func(string, []byte) error { return fs.ErrPermission }
+	_, err = run(t, cfg)
+	require.ErrorIs(t, err, constants.ErrWriteOutput)
+	require.ErrorIs(t, err, fs.ErrPermission)
+}
+
+// TestRunFileMarkersStayInTheWorkingDirectory pins that a file marker cannot
+// name a file outside the working directory, nor, under --sandbox, any file.
+func TestRunFileMarkersStayInTheWorkingDirectory(t *testing.T) {
+	t.Parallel()
+	written := map[string]string{}
+	cfg := baseConfig()
+	cfg.StdinEnabled = true
+	cfg.WriteFile = func(name string, data []byte) error {
+		written[name] = string(data)
+		return nil
+	}
+	for _, path := range []string{"/etc/passwd", "../x", "deploy/../../x", ".."} {
+		cfg.Source = strings.NewReader("---# file: " + path + "\nx\n")
+		_, err := run(t, cfg)
+		require.ErrorIs(t, err, constants.ErrOutputPath, path)
+		assert.Contains(t, err.Error(), path)
+	}
+
+	cfg.Source = strings.NewReader("---# file: deploy/../a.txt\na\n")
+	_, err := run(t, cfg)
+	require.NoError(t, err)
+
+	cfg.SandboxEnabled = true
+	cfg.Source = strings.NewReader("---# file: b.txt\nb\n")
+	_, err = run(t, cfg)
+	require.ErrorIs(t, err, constants.ErrSandboxedOutput)
+	assert.Equal(t, map[string]string{"deploy/../a.txt": "a\n"}, written)
+}
+
+// TestRunValidateOutput pins that each output is checked as the type of file
+// it is — the run's output as its template's name says, the others as their
+// paths do — and formatted in place of its rendering when asked.
+func TestRunValidateOutput(t *testing.T) {
+	t.Parallel()
+	written := map[string]string{}
+	cfg := baseConfig()
+	cfg.Templates = render.TemplateFiles{"config.json.tmpl"}
+	cfg.ReadFile = mapReadFile(map[string]string{
+		"config.json.tmpl": "{\"name\": \"{{ .Name }}\"}\n---# file: app.yaml\nname: {{ .Name }}\n",
+	})
+	cfg.WriteFile = func(name string, data []byte) error {
+		written[name] = string(data)
+		return nil
+	}
+	cfg.Assignments = render.AssignmentTokens{"--name=web"}
+	cfg.ValidateOutputEnabled = true
+
+	result, err := run(t, cfg)
+	require.NoError(t, err)
+	assert.Equal(t, "{\"name\": \"web\"}\n\n", string(result.Output()))
+
+	cfg.FormatOutputEnabled = true
+	result, err = run(t, cfg)
+	require.NoError(t, err)
+	assert.Equal(t, "{\n  \"name\": \"web\"\n}\n", string(result.Output()))
+
+	cfg.Templates = render.TemplateFiles{"main.go.tmpl"}
+	cfg.ReadFile = mapReadFile(map[string]string{"main.go.tmpl": "package {{ .Name }}\nfunc f( ) {}\n"})
+	result, err = run(t, cfg)
+	require.NoError(t, err)
+	assert.Equal(t, "package web\n\nfunc f() {}\n", string(result.Output()), "formatted Go ends in one newline")
+
+	cfg.Templates = render.TemplateFiles{"config.json.tmpl"}
+	cfg.ReadFile = mapReadFile(map[string]string{
+		"config.json.tmpl": "{\"name\": \"{{ .Name }}\"}\n---# file: app.yaml\nname: {{ .Name }}\n",
+	})
+
+	cfg.Assignments = render.AssignmentTokens{"--name=web: db"}
+	_, err = run(t, cfg)
+	require.ErrorIs(t, err, constants.ErrValidateOutput)
+	var located *constants.TemplateError
+	require.ErrorAs(t, err, &located)
+	assert.Equal(t, "app.yaml", located.Template)
+	assert.Equal(t, 1, located.Line)
+	assert.Equal(t, "name: web: db", located.Source)
+}
+
+// TestRunTestingSequencesSpanTheRun pins that in testing mode the sequence
+// functions count from one in each run, and that a run's templates share the
+// count rather than each starting over.
+func TestRunTestingSequencesSpanTheRun(t *testing.T) {
+	t.Parallel()
+	cfg := baseConfig()
+	cfg.TestingEnabled = true
+	cfg.Templates = render.TemplateFiles{"a.tmpl", "b.tmpl"}
+	cfg.ReadFile = mapReadFile(map[string]string{
+		"a.tmpl": `{{ next }}{{ next }} {{ keynext "k" }}`,
+		"b.tmpl": `{{ next }} {{ keynext "k" }}`,
+	})
+
+	for range 2 {
+		result, err := run(t, cfg)
+		require.NoError(t, err)
+		assert.Equal(t, "12 1\n3 2\n", string(result.Output()))
+	}
+}
+
+// TestRunInPlace pins rendering in place: each region is rendered from the
+// template its begin marker names, relative to the file, or holds inline, and
+// only the regions change; in check mode nothing is written, and every region
+// out of date fails the run.
+func TestRunInPlace(t *testing.T) {
+	t.Parallel()
+	readme := "# App\n<!-- renderizer:begin inline v{{ .Version }} -->\nv1\n<!-- renderizer:end -->\n" +
+		"## Flags\n# renderizer:begin flags.tmpl\n# renderizer:end\nhand-written\n"
+	files := map[string]string{"docs/README.md": readme, "docs/flags.tmpl": "{{ range .Flags }}--{{ . }}\n{{ end }}"}
+	written := map[string]string{}
+	cfg := baseConfig()
+	cfg.ReadFile = mapReadFile(files)
+	cfg.WriteFile = func(name string, data []byte) error {
+		written[name] = string(data)
+		return nil
+	}
+	cfg.InPlace = render.InPlaceFiles{"docs/README.md"}
+	cfg.Assignments = render.AssignmentTokens{"--version=2", "--flags=a", "--flags=b"}
+
+	cfg.CheckEnabled = true
+	_, err := run(t, cfg)
+	require.ErrorIs(t, err, constants.ErrStaleRegion)
+	assert.Contains(t, err.Error(), "docs/README.md:2")
+	assert.Contains(t, err.Error(), "docs/README.md:6")
+	assert.Empty(t, written)
+
+	cfg.CheckEnabled = false
+	result, err := run(t, cfg)
+	require.NoError(t, err)
+	want := "# App\n<!-- renderizer:begin inline v{{ .Version }} -->\nv2\n<!-- renderizer:end -->\n" +
+		"## Flags\n# renderizer:begin flags.tmpl\n--a\n--b\n# renderizer:end\nhand-written\n"
+	assert.Equal(t, map[string]string{"docs/README.md": want}, written)
+	assert.Equal(t, []render.Output{{Path: "docs/README.md", Content: []byte(want)}}, result.Outputs)
+
+	files["docs/README.md"] = want
+	cfg.CheckEnabled = true
+	_, err = run(t, cfg)
+	require.NoError(t, err, "a file already up to date passes the check")
+
+	files["docs/README.md"] = "a\n# renderizer:begin inline {{ .Nope }}\n# renderizer:end\n"
+	_, err = run(t, cfg)
+	require.ErrorIs(t, err, constants.ErrExecuteTemplate)
+	var located *constants.TemplateError
+	require.ErrorAs(t, err, &located)
+	assert.Equal(t, "docs/README.md", located.Template)
+	assert.Equal(t, 2, located.Line, "an inline template is located on its begin marker's line")
+}
+
+// TestRunInPlaceFrontMatter pins that a region's template renders with its
+// front matter's defaults and required keys, as it would anywhere, and that
+// front matter naming an output file fails rather than being ignored.
+func TestRunInPlaceFrontMatter(t *testing.T) {
+	t.Parallel()
+	files := map[string]string{
+		"README.md":   "# renderizer:begin v.tmpl\n# renderizer:end\n",
+		"v.tmpl":      "---\ndefaults:\n  Port: 80\nrequired: [Version]\n---\nv{{ .Version }}:{{ .Port }}\n",
+		"output.tmpl": "---\noutput: out.txt\n---\nx\n",
+	}
+	written := map[string]string{}
+	cfg := baseConfig()
+	cfg.ReadFile = mapReadFile(files)
+	cfg.WriteFile = func(name string, data []byte) error {
+		written[name] = string(data)
+		return nil
+	}
+	cfg.InPlace = render.InPlaceFiles{"README.md"}
+
+	_, err := run(t, cfg)
+	require.ErrorIs(t, err, constants.ErrRequiredKey)
+
+	cfg.Assignments = render.AssignmentTokens{"--version=2"}
+	_, err = run(t, cfg)
+	require.NoError(t, err)
+	assert.Equal(t, "# renderizer:begin v.tmpl\nv2:80\n# renderizer:end\n", written["README.md"])
+
+	files["README.md"] = "a\n# renderizer:begin output.tmpl\n# renderizer:end\n"
+	_, err = run(t, cfg)
+	require.ErrorIs(t, err, constants.ErrRegionOutput)
+	assert.Contains(t, err.Error(), "README.md:2")
+	assert.NotContains(t, written, "out.txt")
 }
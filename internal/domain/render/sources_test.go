@@ -20,7 +20,7 @@ func TestRunDiscoversDefaultTemplate(t *testing.T) {
 
 	result, err := run(t, cfg)
 	require.NoError(t, err)
-	assert.Equal(t, "Discovered ok\n", string(result.Output))
+	assert.Equal(t, "Discovered ok\n", string(result.Output()))
 }
 
 func TestRunMissingTemplate(t *testing.T) {
@@ -52,5 +52,5 @@ func TestRunMultipleTemplates(t *testing.T) {
 
 	result, err := run(t, cfg)
 	require.NoError(t, err)
-	assert.Equal(t, "First X\nSecond X\n", string(result.Output))
+	assert.Equal(t, "First X\nSecond X\n", string(result.Output()))
 }
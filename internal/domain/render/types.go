@@ -1,5 +1,10 @@
 package render
 
+import (
+	"io/fs"
+	"time"
+)
+
 // Named types for every Config field. Flag-bound fields are converted from the
 // CLI tier via pointer conversion; injected seams are set by the composition
 // root (cmd) so every IO branch is reachable from a test.
@@ -8,16 +13,83 @@ type (
 	SettingsFiles []string
 	// MissingKeyOption is the text/template missingkey option (--missing).
 	MissingKeyOption string
+	// TemplateDelims are the left and right action delimiters, comma-separated
+	// (--delims).
+	TemplateDelims string
+	// HTMLEnabled parses every template with html/template, escaping its
+	// output for the HTML context (--html).
+	HTMLEnabled bool
+	// HTMLAutoEnabled parses the templates named *.html.tmpl with
+	// html/template (--html-auto).
+	HTMLAutoEnabled bool
+	// LibraryPaths name the directories, globs, or files of partials parsed
+	// alongside every template (--lib).
+	LibraryPaths []string
+	// InPlaceFiles are the files whose marked regions are rendered in place
+	// (--in-place).
+	InPlaceFiles []string
+	// CheckEnabled fails when an --in-place region is out of date rather than
+	// rewriting it (--check).
+	CheckEnabled bool
+	// ProfileName selects the `.<name>.<profile>.yaml` settings overlay (--profile).
+	ProfileName string
+	// MergeStrategy is how settings files and repeated assignments combine (--merge).
+	MergeStrategy string
 	// EnvironmentName is the context key the environment map is bound under (--environment).
 	EnvironmentName string
+	// EnvironmentPatterns are path.Match globs over environment variable names
+	// (--env-allow, --env-deny).
+	EnvironmentPatterns []string
+	// EnvironmentPrefix selects the environment variables read as variables,
+	// with the prefix stripped (--env-prefix).
+	EnvironmentPrefix string
 	// StdinEnabled forces reading the template from stdin (--stdin).
 	StdinEnabled bool
 	// TestingEnabled makes nondeterministic template functions reproducible (--testing).
 	TestingEnabled bool
+	// TestSeed seeds the sequence testing mode's random functions draw from
+	// (--seed).
+	TestSeed uint64
+	// TestClock is the time testing mode's clock is fixed at, or the default
+	// when zero (--now).
+	TestClock time.Time
 	// DebuggingEnabled enables debug logging (--debugging).
 	DebuggingEnabled bool
 	// VerboseEnabled enables verbose logging (--verbose).
 	VerboseEnabled bool
+	// InferenceDisabled keeps untyped variable and settings values as strings
+	// rather than guessing their types (--no-infer).
+	InferenceDisabled bool
+	// TemplateSettingsEnabled renders settings files as templates before they
+	// are parsed (--template-settings).
+	TemplateSettingsEnabled bool
+	// SandboxEnabled restricts templates to the functions without side effects
+	// and hides the environment map from them (--sandbox).
+	SandboxEnabled bool
+	// FuncNames name template functions to allow into the sandbox
+	// (--allow-func) or deny (--deny-func).
+	FuncNames []string
+	// KeepGoingEnabled renders every template even after one fails, reporting
+	// the failures together (--keep-going).
+	KeepGoingEnabled bool
+	// ValidateOutputEnabled fails on an output that is not well formed for the
+	// type its name says it holds (--validate-output).
+	ValidateOutputEnabled bool
+	// FormatOutputEnabled rewrites each output in its type's canonical form,
+	// validating it on the way (--format-output).
+	FormatOutputEnabled bool
+	// RenderTimeout bounds the time each template may take to render
+	// (--timeout).
+	RenderTimeout time.Duration
+	// OutputLimit bounds the bytes each template may render (--max-output).
+	OutputLimit int64
+	// IterationLimit bounds the range iterations each template may run, and
+	// the elements until and untilStep may build; seq and repeat are not
+	// counted (--max-iterations).
+	IterationLimit int64
+	// ExplainEnabled prints the merged context with each value's origin
+	// instead of rendering (--explain).
+	ExplainEnabled bool
 
 	// Capitalization is the initial title-casing state for variable names.
 	Capitalization bool
@@ -32,9 +104,20 @@ type (
 // ReadFileFunc reads a named file. os.ReadFile satisfies it in production.
 type ReadFileFunc func(name string) ([]byte, error)
 
+// WriteFileFunc writes a named output file, creating its directory.
+type WriteFileFunc func(name string, data []byte) error
+
 // ExistsFunc reports whether a path exists, used for default-template discovery.
 type ExistsFunc func(name string) bool
 
+// ReadDirFunc lists a directory, used to find library files. os.ReadDir
+// satisfies it in production.
+type ReadDirFunc func(name string) ([]fs.DirEntry, error)
+
+// GlobFunc returns the paths matching a pattern, used to find library files.
+// filepath.Glob satisfies it in production.
+type GlobFunc func(pattern string) ([]string, error)
+
 // GetwdFunc returns the working directory, used to derive default names.
 type GetwdFunc func() (string, error)
 
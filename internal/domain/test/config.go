@@ -0,0 +1,13 @@
+package test
+
+// Config holds everything Run needs: the suite directory, whether to update
+// its expectations, and the injected IO and render seams. It carries no
+// behavior.
+type Config struct {
+	Render        RenderFunc
+	ReadFile      ReadFileFunc
+	WriteFile     WriteFileFunc
+	ReadDir       ReadDirFunc
+	Dir           SuiteDir
+	UpdateEnabled UpdateEnabled
+}
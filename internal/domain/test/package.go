@@ -0,0 +1,8 @@
+// Package test orchestrates the test command: it discovers the golden-file
+// test cases under a directory, renders each through the injected render
+// seam, and compares what it renders with what the case expects, rewriting
+// the expectation instead when asked to. It delegates reading the cases and
+// diffing them to internal/golden and holds no CLI logic; how an invocation
+// renders is the app tier's to say. This is the domain tier between the
+// app/cmd tier and the implementation packages.
+package test
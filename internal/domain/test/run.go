@@ -0,0 +1,185 @@
+package test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/gomatic/renderizer/internal/constants"
+	"github.com/gomatic/renderizer/internal/domain"
+	"github.com/gomatic/renderizer/internal/golden"
+)
+
+// Status is how a test case fared.
+type Status string
+
+// The statuses of a test case.
+const (
+	StatusPassed  Status = "ok"
+	StatusFailed  Status = "FAIL"
+	StatusUpdated Status = "updated"
+)
+
+// Case is the outcome of one test case: its directory, relative to the suite,
+// how it fared and, when it failed, why: the diff of what it expects to what
+// it rendered, or the failure that stopped it rendering.
+type Case struct {
+	Err    error
+	Name   string
+	Diff   string
+	Status Status
+}
+
+// Result is the outcome of a test run: each of its cases, in order.
+type Result struct {
+	Cases []Case
+}
+
+// Output returns the report of the run: a line per case, followed by why it
+// failed, and a summary, or nothing when it found no cases.
+func (r Result) Output() []byte {
+	if len(r.Cases) == 0 {
+		return nil
+	}
+	var out bytes.Buffer
+	counts := map[Status]int{}
+	for _, tested := range r.Cases {
+		counts[tested.Status]++
+		fmt.Fprintf(&out, "%-8s %s\n", tested.Status, tested.Name)
+		if tested.Err != nil {
+			fmt.Fprintf(&out, "\t%v\n", tested.Err)
+		}
+		for line := range strings.Lines(tested.Diff) {
+			out.WriteString("\t" + line)
+		}
+	}
+	fmt.Fprintf(&out, "%d passed, %d failed, %d updated\n",
+		counts[StatusPassed], counts[StatusFailed], counts[StatusUpdated])
+	return out.Bytes()
+}
+
+// Run discovers the test cases under the suite directory — each directory
+// holding an expectation, hidden directories aside — and tests each: it
+// renders the invocations of the case's script in turn and compares their
+// output with the expectation. Under --update an expectation that differs is
+// rewritten rather than failing its case. Every failed case is reported, and
+// the run fails with ErrTestCaseFailed for each.
+func Run(ctx context.Context, logger *slog.Logger, cfg Config, _ ...domain.Argument) (Result, error) {
+	dir := string(cfg.Dir)
+	dirs, err := discover(cfg, dir)
+	if err != nil {
+		return Result{}, err
+	}
+	if len(dirs) == 0 {
+		return Result{}, constants.ErrMissingTestCases.With(nil, dir)
+	}
+	var (
+		result Result
+		failed []error
+	)
+	for _, caseDir := range dirs {
+		name, _ := filepath.Rel(dir, caseDir)
+		tested := check(ctx, cfg, caseDir, name)
+		tested.Name = name
+		logger.Debug("Tested case.", "case", tested.Name, "status", tested.Status)
+		if tested.Status == StatusFailed {
+			failed = append(failed, constants.ErrTestCaseFailed.With(nil, tested.Name))
+		}
+		result.Cases = append(result.Cases, tested)
+	}
+	return result, errors.Join(failed...)
+}
+
+// discover returns the directories of the test cases under dir, dir first
+// when it is one, and the rest in name order.
+func discover(cfg Config, dir string) ([]string, error) {
+	entries, err := cfg.ReadDir(dir)
+	if err != nil {
+		return nil, constants.ErrMissingTestCases.With(err, dir)
+	}
+	var dirs []string
+	if slices.ContainsFunc(entries, isExpectation) {
+		dirs = append(dirs, dir)
+	}
+	for _, entry := range entries {
+		nested, err := discoverNested(cfg, dir, entry)
+		if err != nil {
+			return nil, err
+		}
+		dirs = append(dirs, nested...)
+	}
+	return dirs, nil
+}
+
+// isExpectation reports whether entry is a test case's expectation.
+func isExpectation(entry fs.DirEntry) bool {
+	return !entry.IsDir() && entry.Name() == golden.ExpectFile
+}
+
+// discoverNested returns the directories of the test cases under entry of
+// dir, when it is a directory that is not hidden.
+func discoverNested(cfg Config, dir string, entry fs.DirEntry) ([]string, error) {
+	if !entry.IsDir() || strings.HasPrefix(entry.Name(), ".") {
+		return nil, nil
+	}
+	return discover(cfg, filepath.Join(dir, entry.Name()))
+}
+
+// check returns how the case named name in dir fares.
+func check(ctx context.Context, cfg Config, dir, name string) Case {
+	expectFile := filepath.Join(dir, golden.ExpectFile)
+	expected, err := cfg.ReadFile(expectFile)
+	if err != nil {
+		return Case{Status: StatusFailed, Err: err}
+	}
+	rendered, err := render(ctx, cfg, dir)
+	switch {
+	case err != nil:
+		return Case{Status: StatusFailed, Err: err}
+	case bytes.Equal(expected, rendered):
+		return Case{Status: StatusPassed}
+	case !bool(cfg.UpdateEnabled):
+		return Case{Status: StatusFailed, Diff: golden.Diff(name, expected, rendered)}
+	}
+	if err := cfg.WriteFile(expectFile, rendered); err != nil {
+		return Case{Status: StatusFailed, Err: constants.ErrWriteOutput.With(err, expectFile)}
+	}
+	return Case{Status: StatusUpdated}
+}
+
+// render returns what the invocations of the script of the case in dir write,
+// in turn.
+func render(ctx context.Context, cfg Config, dir string) ([]byte, error) {
+	script, err := readScript(cfg, dir)
+	if err != nil {
+		return nil, err
+	}
+	var rendered []byte
+	for i, args := range script.Invocations {
+		out, err := cfg.Render(ctx, golden.Invocation{Dir: dir, Args: args, Environ: script.Environ})
+		rendered = append(rendered, out...)
+		if err != nil {
+			return nil, fmt.Errorf("invocation %d (%s): %w", i+1, strings.Join(args, " "), err)
+		}
+	}
+	return rendered, nil
+}
+
+// readScript returns the script of the case in dir, or the default script
+// when it has none.
+func readScript(cfg Config, dir string) (golden.Script, error) {
+	content, err := cfg.ReadFile(filepath.Join(dir, golden.ScriptFile))
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return golden.DefaultScript(), nil
+	case err != nil:
+		return golden.Script{}, err
+	}
+	return golden.ParseScript(filepath.Base(dir), content)
+}
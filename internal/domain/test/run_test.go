@@ -0,0 +1,151 @@
+package test_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"log/slog"
+	"slices"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gomatic/renderizer/internal/constants"
+	"github.com/gomatic/renderizer/internal/domain/test"
+	"github.com/gomatic/renderizer/internal/golden"
+)
+
+func discardLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+// render renders an invocation as its directory, arguments and environment.
+func render(_ context.Context, invocation golden.Invocation) ([]byte, error) {
+	if slices.Contains(invocation.Args, "--fail") {
+		return []byte("partial\n"), errors.New("render boom")
+	}
+	return []byte(invocation.Dir + ": " + strings.Join(append(invocation.Args, invocation.Environ...), " ") + "\n"), nil
+}
+
+func config(files fstest.MapFS, isUpdating bool) test.Config {
+	return test.Config{
+		Render:   render,
+		ReadFile: files.ReadFile,
+		WriteFile: func(name string, data []byte) error {
+			files[name] = &fstest.MapFile{Data: data}
+			return nil
+		},
+		ReadDir:       func(name string) ([]fs.DirEntry, error) { return fs.ReadDir(files, name) },
+		Dir:           "suite",
+		UpdateEnabled: test.UpdateEnabled(isUpdating),
+	}
+}
+
+func file(content string) *fstest.MapFile {
+	return &fstest.MapFile{Data: []byte(content)}
+}
+
+func run(t *testing.T, cfg test.Config) (test.Result, error) {
+	t.Helper()
+	return test.Run(context.Background(), discardLogger(), cfg)
+}
+
+// TestRunDiscoversCases pins that the suite directory and every directory
+// under it holding an expectation is a case, in name order with the suite
+// first, hidden directories aside, and that each renders the invocations of
+// its script, or the default one, in turn.
+func TestRunDiscoversCases(t *testing.T) {
+	t.Parallel()
+	files := fstest.MapFS{
+		"suite/expect.txt":            file("suite: \n"),
+		"suite/b/expect.txt":          file("suite/b: \n"),
+		"suite/a/examples.sh":         file("export X=1\nrenderizer\nrenderizer \"$name.tmpl\" --n=1\n"),
+		"suite/a/expect.txt":          file("suite/a: X=1\nsuite/a: a.tmpl --n=1 X=1\n"),
+		"suite/a/nested/c/expect.txt": file("suite/a/nested/c: \n"),
+		"suite/.hidden/expect.txt":    file("wrong\n"),
+		"suite/empty/readme.txt":      file(""),
+	}
+	result, err := run(t, config(files, false))
+	require.NoError(t, err)
+	var names []string
+	for _, tested := range result.Cases {
+		assert.Equal(t, test.StatusPassed, tested.Status, tested.Name)
+		names = append(names, tested.Name)
+	}
+	assert.Equal(t, []string{".", "a", "a/nested/c", "b"}, names)
+	assert.Equal(t, "ok       .\nok       a\nok       a/nested/c\nok       b\n4 passed, 0 failed, 0 updated\n",
+		string(result.Output()))
+}
+
+func TestRunFailsWithDiff(t *testing.T) {
+	t.Parallel()
+	files := fstest.MapFS{
+		"suite/a/expect.txt": file("suite/a: \n"),
+		"suite/b/expect.txt": file("expected\n"),
+	}
+	result, err := run(t, config(files, false))
+	require.ErrorIs(t, err, constants.ErrTestCaseFailed)
+	assert.Contains(t, err.Error(), "b")
+	require.Len(t, result.Cases, 2)
+	assert.Equal(t, test.StatusPassed, result.Cases[0].Status)
+	failed := result.Cases[1]
+	assert.Equal(t, test.StatusFailed, failed.Status)
+	assert.Contains(t, failed.Diff, "-expected\n+suite/b: \n")
+	output := string(result.Output())
+	assert.Contains(t, output, "FAIL     b\n\t--- b/expect.txt\n")
+	assert.Contains(t, output, "1 passed, 1 failed, 0 updated\n")
+	assert.Equal(t, "expected\n", string(files["suite/b/expect.txt"].Data))
+}
+
+func TestRunUpdates(t *testing.T) {
+	t.Parallel()
+	files := fstest.MapFS{
+		"suite/a/expect.txt": file("suite/a: \n"),
+		"suite/b/expect.txt": file("expected\n"),
+	}
+	result, err := run(t, config(files, true))
+	require.NoError(t, err)
+	assert.Equal(t, test.StatusPassed, result.Cases[0].Status)
+	assert.Equal(t, test.StatusUpdated, result.Cases[1].Status)
+	assert.Equal(t, "suite/b: \n", string(files["suite/b/expect.txt"].Data))
+	assert.Contains(t, string(result.Output()), "1 passed, 0 failed, 1 updated\n")
+}
+
+func TestRunFailsWhenRenderFails(t *testing.T) {
+	t.Parallel()
+	files := fstest.MapFS{
+		"suite/a/examples.sh": file("renderizer\nrenderizer --fail\n"),
+		"suite/a/expect.txt":  file("partial\n"),
+	}
+	result, err := run(t, config(files, true))
+	require.ErrorIs(t, err, constants.ErrTestCaseFailed)
+	require.Len(t, result.Cases, 1)
+	assert.Equal(t, test.StatusFailed, result.Cases[0].Status)
+	assert.ErrorContains(t, result.Cases[0].Err, "invocation 2 (--fail): render boom")
+	assert.Equal(t, "partial\n", string(files["suite/a/expect.txt"].Data))
+}
+
+func TestRunFailsOnScript(t *testing.T) {
+	t.Parallel()
+	files := fstest.MapFS{
+		"suite/a/examples.sh": file("renderizer 'open\n"),
+		"suite/a/expect.txt":  file(""),
+	}
+	result, err := run(t, config(files, false))
+	require.ErrorIs(t, err, constants.ErrTestCaseFailed)
+	assert.ErrorIs(t, result.Cases[0].Err, constants.ErrParseScript)
+}
+
+func TestRunWithoutCases(t *testing.T) {
+	t.Parallel()
+	result, err := run(t, config(fstest.MapFS{"suite/a/readme.txt": file("")}, false))
+	require.ErrorIs(t, err, constants.ErrMissingTestCases)
+	assert.Nil(t, result.Output())
+
+	_, err = run(t, config(fstest.MapFS{}, false))
+	require.ErrorIs(t, err, constants.ErrMissingTestCases)
+}
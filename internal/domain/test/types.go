@@ -0,0 +1,29 @@
+package test
+
+import (
+	"context"
+	"io/fs"
+
+	"github.com/gomatic/renderizer/internal/golden"
+)
+
+// Named types for the test config.
+type (
+	// SuiteDir is the directory the test cases are discovered under.
+	SuiteDir string
+	// UpdateEnabled rewrites each expectation that differs from what its case
+	// renders instead of failing the case (--update).
+	UpdateEnabled bool
+)
+
+// RenderFunc renders one invocation of a test case, returning what it writes.
+type RenderFunc func(ctx context.Context, invocation golden.Invocation) ([]byte, error)
+
+// ReadFileFunc reads a named file. os.ReadFile satisfies it in production.
+type ReadFileFunc func(name string) ([]byte, error)
+
+// WriteFileFunc writes a named file, creating it when needed.
+type WriteFileFunc func(name string, data []byte) error
+
+// ReadDirFunc lists a directory. os.ReadDir satisfies it in production.
+type ReadDirFunc func(name string) ([]fs.DirEntry, error)
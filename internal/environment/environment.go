@@ -4,7 +4,13 @@
 // process environment.
 package environment
 
-import "strings"
+import (
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/gomatic/renderizer/internal/constants"
+)
 
 // Environ returns the process environment as "KEY=VALUE" strings. os.Environ
 // satisfies it in production; tests inject a fixed slice.
@@ -23,3 +29,69 @@ func Load(environ Environ) Variables {
 	}
 	return variables
 }
+
+// WithPrefix returns the variables whose names start with prefix, under their
+// names with the prefix stripped. A variable named exactly prefix names
+// nothing and is dropped, as is everything when prefix is empty.
+func (v Variables) WithPrefix(prefix string) Variables {
+	selected := Variables{}
+	if prefix == "" {
+		return selected
+	}
+	for name, value := range v {
+		if stripped, ok := strings.CutPrefix(name, prefix); ok && stripped != "" {
+			selected[stripped] = value
+		}
+	}
+	return selected
+}
+
+// Filter selects variables by name with path.Match globs: with any Allow
+// patterns only matching names are selected, and names matching a Deny
+// pattern never are, even when allowed.
+type Filter struct {
+	Allow []string
+	Deny  []string
+}
+
+// Validate fails with ErrEnvironmentPattern naming the first malformed
+// pattern, so a mistyped deny pattern cannot silently deny nothing.
+func (f Filter) Validate() error {
+	for _, pattern := range slices.Concat(f.Allow, f.Deny) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return constants.ErrEnvironmentPattern.With(err, pattern)
+		}
+	}
+	return nil
+}
+
+// Select returns the variables filter selects. A malformed pattern matches
+// nothing; Validate reports it.
+func (v Variables) Select(filter Filter) Variables {
+	selected := Variables{}
+	for name, value := range v {
+		isAllowed := len(filter.Allow) == 0 || matchesAny(filter.Allow, name)
+		if isAllowed && !matchesAny(filter.Deny, name) {
+			selected[name] = value
+		}
+	}
+	return selected
+}
+
+// sensitivePatterns match, case-insensitively, the names of values that
+// likely hold credentials.
+var sensitivePatterns = []string{"*TOKEN*", "*SECRET*", "*PASSWORD*"}
+
+// IsSensitive reports whether name looks like it holds a credential, whatever
+// its case, so its value is kept out of diagnostic output.
+func IsSensitive(name string) bool {
+	return matchesAny(sensitivePatterns, strings.ToUpper(name))
+}
+
+// matchesAny reports whether name matches any of patterns.
+func matchesAny(patterns []string, name string) bool {
+	return slices.ContainsFunc(patterns, func(pattern string) bool {
+		matched, err := path.Match(pattern, name)
+		return err == nil && matched
+	})
+}
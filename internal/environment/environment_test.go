@@ -4,7 +4,9 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
+	"github.com/gomatic/renderizer/internal/constants"
 	"github.com/gomatic/renderizer/internal/environment"
 )
 
@@ -22,3 +24,44 @@ func TestLoad(t *testing.T) {
 	}
 	assert.Equal(t, want, got)
 }
+
+func TestWithPrefix(t *testing.T) {
+	t.Parallel()
+	variables := environment.Variables{"RNDR_NAME": "x", "RNDR_": "bare", "RNDR_A__B": "y", "HOME": "/home"}
+
+	assert.Equal(t, environment.Variables{"NAME": "x", "A__B": "y"}, variables.WithPrefix("RNDR_"))
+	assert.Empty(t, variables.WithPrefix(""), "an empty prefix selects nothing rather than everything")
+}
+
+func TestSelect(t *testing.T) {
+	t.Parallel()
+	variables := environment.Variables{"CI_JOB": "1", "CI_TOKEN": "t", "HOME": "/home", "USER": "alice"}
+
+	assert.Equal(t, variables, variables.Select(environment.Filter{}), "no patterns select everything")
+	assert.Equal(t,
+		environment.Variables{"CI_JOB": "1", "USER": "alice"},
+		variables.Select(environment.Filter{Allow: []string{"CI_*", "USER"}, Deny: []string{"*TOKEN*"}}),
+		"deny wins over allow")
+	assert.Equal(t,
+		environment.Variables{"CI_JOB": "1", "CI_TOKEN": "t"},
+		variables.Select(environment.Filter{Deny: []string{"HOME", "US?R"}}))
+}
+
+func TestFilterValidate(t *testing.T) {
+	t.Parallel()
+	require.NoError(t, environment.Filter{Allow: []string{"CI_*"}, Deny: []string{"[AB]*"}}.Validate())
+
+	err := environment.Filter{Deny: []string{"[unclosed"}}.Validate()
+	require.ErrorIs(t, err, constants.ErrEnvironmentPattern)
+	assert.Contains(t, err.Error(), "[unclosed")
+}
+
+func TestIsSensitive(t *testing.T) {
+	t.Parallel()
+	for _, name := range []string{"GITHUB_TOKEN", "db_password", "ClientSecret", "TOKEN"} {
+		assert.True(t, environment.IsSensitive(name), name)
+	}
+	for _, name := range []string{"HOME", "TOKE", "PASS"} {
+		assert.False(t, environment.IsSensitive(name), name)
+	}
+}
@@ -0,0 +1,214 @@
+// Package format checks that rendered output is well formed for the type of
+// file it is, and canonicalizes it. The type is inferred from the name of the
+// output, a trailing .tmpl aside: YAML, every document of a multi-document
+// stream, JSON, XML, and Go. Output of any other type passes unchecked. It is
+// an implementation package: pure, with no IO and no CLI knowledge.
+//
+// A malformed output fails with ErrValidateOutput, located at the line of the
+// output the parser stopped on, so a broken manifest is caught where it was
+// generated rather than where it is applied.
+package format
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"go/scanner"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	gofmt "go/format"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/gomatic/renderizer/internal/constants"
+)
+
+// Kind is the type of file an output is.
+type Kind string
+
+// The kinds of output checked; any other is Unknown.
+const (
+	Unknown Kind = ""
+	YAML    Kind = "yaml"
+	JSON    Kind = "json"
+	XML     Kind = "xml"
+	Go      Kind = "go"
+)
+
+// templateSuffix is the suffix a template's name carries over the name of the
+// file it renders.
+const templateSuffix = ".tmpl"
+
+// yamlLine matches the position yaml.v3 prefixes its syntax errors with.
+var yamlLine = regexp.MustCompile(`^yaml: line (\d+): (.*)$`)
+
+// Of returns the kind of the output named name.
+func Of(name string) Kind {
+	switch strings.ToLower(filepath.Ext(strings.TrimSuffix(name, templateSuffix))) {
+	case ".yaml", ".yml":
+		return YAML
+	case ".json":
+		return JSON
+	case ".xml":
+		return XML
+	case ".go":
+		return Go
+	}
+	return Unknown
+}
+
+// Validate fails with ErrValidateOutput unless content, the output named
+// name, is well formed for its kind.
+func Validate(name string, content []byte) error {
+	var err error
+	switch Of(name) {
+	case YAML:
+		err = validateYAML(content)
+	case JSON:
+		err = validateJSON(content)
+	case XML:
+		err = validateXML(content)
+	case Go:
+		_, err = gofmt.Source(content)
+	case Unknown:
+	}
+	if err != nil {
+		return located(name, content, err)
+	}
+	return nil
+}
+
+// Format returns content, the output named name, in its kind's canonical
+// form: JSON indented by two spaces, Go as gofmt prints it. YAML and XML have
+// no canonical form that keeps their comments and layout, so they are only
+// validated. Content that is not well formed fails as Validate fails.
+func Format(name string, content []byte) ([]byte, error) {
+	var (
+		formatted []byte
+		err       error
+	)
+	switch Of(name) {
+	case JSON:
+		formatted, err = indentJSON(content)
+	case Go:
+		formatted, err = gofmt.Source(content)
+	case YAML, XML, Unknown:
+		return content, Validate(name, content)
+	}
+	if err != nil {
+		return nil, located(name, content, err)
+	}
+	return formatted, nil
+}
+
+// validateYAML decodes every document of content.
+func validateYAML(content []byte) error {
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	for {
+		var document yaml.Node
+		if err := decoder.Decode(&document); errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
+
+// validateJSON decodes content as a single JSON value.
+func validateJSON(content []byte) error {
+	var value any
+	return json.Unmarshal(content, &value)
+}
+
+// indentJSON returns content, validated, indented by two spaces.
+func indentJSON(content []byte) ([]byte, error) {
+	if err := validateJSON(content); err != nil {
+		return nil, err
+	}
+	var out bytes.Buffer
+	if err := json.Indent(&out, content, "", "  "); err != nil {
+		return nil, err
+	}
+	return out.Bytes(), nil
+}
+
+// validateXML reads every token of content.
+func validateXML(content []byte) error {
+	decoder := xml.NewDecoder(bytes.NewReader(content))
+	for {
+		if _, err := decoder.Token(); errors.Is(err, io.EOF) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
+
+// located wraps err, the parser's failure on content, the output named name,
+// as a TemplateError at the line of the output it stopped on, when the
+// parser says.
+func located(name string, content []byte, err error) error {
+	line, column, reason := position(content, err)
+	return &constants.TemplateError{
+		Cause:    constants.ErrValidateOutput.With(err, name),
+		Template: name,
+		Source:   sourceLine(content, line),
+		Reason:   reason,
+		Line:     line,
+		Column:   column,
+	}
+}
+
+// position returns the one-based line and column err, a parser's failure on
+// content, occurred at, zero when it does not say, and its reason.
+func position(content []byte, err error) (line, column int, reason string) {
+	var (
+		jsonErr *json.SyntaxError
+		xmlErr  *xml.SyntaxError
+		goErrs  scanner.ErrorList
+	)
+	switch {
+	case errors.As(err, &jsonErr):
+		line, column = offsetPosition(content, int(jsonErr.Offset))
+		return line, column, jsonErr.Error()
+	case errors.As(err, &xmlErr):
+		return xmlErr.Line, 0, xmlErr.Msg
+	case errors.As(err, &goErrs) && len(goErrs) > 0:
+		return goErrs[0].Pos.Line, goErrs[0].Pos.Column, goErrs[0].Msg
+	}
+	return yamlPosition(err)
+}
+
+// yamlPosition returns the line err, a yaml.v3 failure, occurred at, and its
+// reason. yaml.v3 only leaves the line out of a failure on the first.
+func yamlPosition(err error) (line, column int, reason string) {
+	match := yamlLine.FindStringSubmatch(err.Error())
+	if match == nil {
+		return 1, 0, strings.TrimPrefix(err.Error(), "yaml: ")
+	}
+	line, _ = strconv.Atoi(match[1])
+	return line, 0, match[2]
+}
+
+// offsetPosition returns the one-based line and column of the byte at offset
+// in content. encoding/json reports the offset just past the failing byte.
+func offsetPosition(content []byte, offset int) (line, column int) {
+	offset = min(max(offset-1, 0), len(content))
+	before := content[:offset]
+	return bytes.Count(before, []byte("\n")) + 1, offset - bytes.LastIndexByte(before, '\n')
+}
+
+// sourceLine returns the one-based line of content, or empty when there is
+// none.
+func sourceLine(content []byte, line int) string {
+	lines := strings.Split(string(content), "\n")
+	if line < 1 || line > len(lines) {
+		return ""
+	}
+	return strings.TrimRight(lines[line-1], "\r")
+}
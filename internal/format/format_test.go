@@ -0,0 +1,89 @@
+package format_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gomatic/renderizer/internal/constants"
+	"github.com/gomatic/renderizer/internal/format"
+)
+
+func TestOf(t *testing.T) {
+	t.Parallel()
+	for name, want := range map[string]format.Kind{
+		"deploy.yaml.tmpl": format.YAML,
+		"values.YML":       format.YAML,
+		"config.json":      format.JSON,
+		"pom.xml.tmpl":     format.XML,
+		"zz_generated.go":  format.Go,
+		"index.html":       format.Unknown,
+		"stdin":            format.Unknown,
+	} {
+		assert.Equal(t, want, format.Of(name), name)
+	}
+}
+
+// TestValidate pins that a malformed output fails at the line of the output
+// the parser stopped on — in a multi-document YAML stream, the line of the
+// stream — and that well-formed output, or output of an unchecked type, passes.
+func TestValidate(t *testing.T) {
+	t.Parallel()
+	for _, tc := range []struct {
+		name    string
+		content string
+		source  string
+		line    int
+	}{
+		{name: "a.yaml", content: "a: 1\n---\nb: 2\nc: [\n", line: 4, source: "c: ["},
+		{name: "a.yaml", content: "a: 1\n---\nb: 2\n  c: 3\n", line: 4, source: "  c: 3"},
+		{name: "a.yaml", content: "a: b: c\n", line: 1, source: "a: b: c"},
+		{name: "a.json", content: "{\n  \"a\": 1,\n  \"b\" 2\n}", line: 3, source: `  "b" 2`},
+		{name: "a.xml", content: "<a>\n<b></a>\n", line: 2, source: "<b></a>"},
+		{name: "a.go", content: "package a\n\nfunc f() {\n", line: 3, source: "func f() {"},
+	} {
+		err := format.Validate(tc.name, []byte(tc.content))
+		require.ErrorIs(t, err, constants.ErrValidateOutput, tc.content)
+		var located *constants.TemplateError
+		require.True(t, errors.As(err, &located), tc.content)
+		assert.Equal(t, tc.name, located.Template)
+		assert.Equal(t, tc.line, located.Line, tc.content)
+		assert.Equal(t, tc.source, located.Source, tc.content)
+		assert.NotEmpty(t, located.Reason)
+		assert.Equal(t, constants.StageValidate, located.Stage())
+	}
+
+	for name, content := range map[string]string{
+		"a.yaml": "---\na: 1\n---\nb: [2]\n",
+		"a.json": `{"a": [1, 2]}`,
+		"a.xml":  "<?xml version=\"1.0\"?>\n<a><b/></a>\n",
+		"a.go":   "package a\nfunc f(){}\n",
+		"a.txt":  "{not: [valid",
+	} {
+		require.NoError(t, format.Validate(name, []byte(content)), name)
+	}
+}
+
+// TestFormat pins the canonical forms: JSON indented by two spaces, Go as
+// gofmt prints it, and every other type unchanged once validated.
+func TestFormat(t *testing.T) {
+	t.Parallel()
+	formatted, err := format.Format("a.json", []byte(`{"a":[1,2],"b":{}}`))
+	require.NoError(t, err)
+	assert.Equal(t, "{\n  \"a\": [\n    1,\n    2\n  ],\n  \"b\": {}\n}", string(formatted))
+
+	formatted, err = format.Format("a.go.tmpl", []byte("package a\nfunc f( ) { return }"))
+	require.NoError(t, err)
+	assert.Equal(t, "package a\n\nfunc f() { return }\n", string(formatted))
+
+	formatted, err = format.Format("a.yaml", []byte("a:   1 # kept\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "a:   1 # kept\n", string(formatted))
+
+	_, err = format.Format("a.json", []byte(`{"a":}`))
+	require.ErrorIs(t, err, constants.ErrValidateOutput)
+	_, err = format.Format("a.yaml", []byte("a: [\n"))
+	require.ErrorIs(t, err, constants.ErrValidateOutput)
+}
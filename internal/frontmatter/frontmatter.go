@@ -0,0 +1,163 @@
+// Package frontmatter splits a template's front matter from its body. Front
+// matter is an optional YAML block between two `---` lines at the very top of
+// a template, describing how it renders: the defaults it falls back on, the
+// file it renders to, its missingkey option and action delimiters, and the
+// keys it cannot render without. It is an implementation package: pure, with
+// no IO and no CLI knowledge.
+//
+// A YAML template often starts with a `---` document separator, so a block is
+// only front matter when it holds a front-matter key and every key in it is
+// one; otherwise it is the start of the body, and the template has no front
+// matter. An empty or comment-only block is a document like any other.
+package frontmatter
+
+import (
+	"bytes"
+	"errors"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/gomatic/renderizer/internal/constants"
+)
+
+// fence is the line opening and closing the front matter.
+const fence = "---"
+
+type (
+	// Defaults are the values a template falls back on for the names its data
+	// leaves unset.
+	Defaults map[string]any
+	// RequiredKeys are the dotted paths of the values a template cannot render
+	// without.
+	RequiredKeys []string
+	// OutputPath is the file a template renders to, itself a template.
+	OutputPath string
+	// MissingKey is the template's text/template missingkey option.
+	MissingKey string
+	// Delims are the template's action delimiters, comma-separated.
+	Delims string
+)
+
+// Matter is a template's front matter. The zero value describes a template
+// without any.
+type Matter struct {
+	Defaults Defaults     `yaml:"defaults"`
+	Required RequiredKeys `yaml:"required"`
+	Output   OutputPath   `yaml:"output"`
+	Missing  MissingKey   `yaml:"missingkey"`
+	Delims   Delims       `yaml:"delims"`
+	// Lines counts the lines of the source the front matter took, fences
+	// included: how far the body is from the top of the source.
+	Lines int `yaml:"-"`
+}
+
+// UnmarshalYAML decodes the defaults with nested maps as plain maps, as
+// settings decode, rather than as Defaults.
+func (d *Defaults) UnmarshalYAML(node *yaml.Node) error {
+	var values map[string]any
+	if err := node.Decode(&values); err != nil {
+		return err
+	}
+	*d = values
+	return nil
+}
+
+// keys are the keys front matter may hold.
+var keys = []string{"defaults", "required", "output", "missingkey", "delims"}
+
+// Split returns the front matter at the top of source, if any, and the body
+// following it. A block holding only front-matter keys that does not decode
+// as front matter fails with ErrParseFrontMatter.
+func Split(source []byte) (Matter, []byte, error) {
+	block, body, lines, isFenced := fenced(source)
+	if !isFenced || !isFrontMatter(block) {
+		return Matter{}, source, nil
+	}
+	var matter Matter
+	if err := yaml.Unmarshal(block, &matter); err != nil {
+		return Matter{}, nil, constants.ErrParseFrontMatter.With(err)
+	}
+	matter.Lines = lines
+	return matter, body, nil
+}
+
+// fenced returns the block between a fence on the first line of source and
+// the next fence, the body after it, and how many lines they took, or reports
+// that source does not start with a fenced block.
+func fenced(source []byte) (block, body []byte, lines int, isFenced bool) {
+	split := bytes.SplitAfter(source, []byte("\n"))
+	if !isFence(split[0]) {
+		return nil, nil, 0, false
+	}
+	for i := 1; i < len(split); i++ {
+		if isFence(split[i]) {
+			return bytes.Join(split[1:i], nil), bytes.Join(split[i+1:], nil), i + 1, true
+		}
+	}
+	return nil, nil, 0, false
+}
+
+// isFence reports whether line is a fence.
+func isFence(line []byte) bool {
+	return strings.TrimRight(string(line), "\r\n") == fence
+}
+
+// isFrontMatter reports whether block is a YAML mapping holding at least one
+// key, and only front-matter keys.
+func isFrontMatter(block []byte) bool {
+	var mapping map[string]yaml.Node
+	if err := yaml.Unmarshal(block, &mapping); err != nil || len(mapping) == 0 {
+		return false
+	}
+	for key := range mapping {
+		if !slices.Contains(keys, key) {
+			return false
+		}
+	}
+	return true
+}
+
+// Shift moves err, when it is a failure located in the body of the template
+// named name, to the line it is on in the template's source, below the front
+// matter. It returns err.
+func (m Matter) Shift(name string, err error) error {
+	var located *constants.TemplateError
+	if m.Lines > 0 && errors.As(err, &located) && located.Template == name && located.Line > 0 {
+		located.Line += m.Lines
+	}
+	return err
+}
+
+// Check fails with ErrRequiredKey, naming the first of the required keys data
+// lacks, unless data holds a value at each: a key is a dotted path through
+// nested maps, and a nil value is as good as none.
+func (m Matter) Check(data map[string]any) error {
+	for _, key := range m.Required {
+		if !holds(data, strings.Split(key, ".")) {
+			return constants.ErrRequiredKey.With(nil, key)
+		}
+	}
+	return nil
+}
+
+// holds reports whether data holds a non-nil value at path, through nested maps
+// and into the environment's map of strings.
+func holds(data map[string]any, path []string) bool {
+	value, isPresent := data[path[0]]
+	if !isPresent || value == nil {
+		return false
+	}
+	if len(path) == 1 {
+		return true
+	}
+	switch nested := value.(type) {
+	case map[string]any:
+		return holds(nested, path[1:])
+	case map[string]string:
+		_, isPresent := nested[path[1]]
+		return isPresent && len(path) == 2
+	}
+	return false
+}
@@ -0,0 +1,118 @@
+package frontmatter_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gomatic/renderizer/internal/constants"
+	"github.com/gomatic/renderizer/internal/frontmatter"
+)
+
+// TestSplit pins what counts as front matter: a fenced block at the very top
+// holding only front-matter keys. Anything else — a YAML template opening with
+// a document separator above all — is the body, returned unchanged.
+func TestSplit(t *testing.T) {
+	t.Parallel()
+	for _, tc := range []struct {
+		name       string
+		source     string
+		wantBody   string
+		wantMatter frontmatter.Matter
+	}{
+		{
+			name:     "none",
+			source:   "Hello\n",
+			wantBody: "Hello\n",
+		},
+		{
+			name: "every key",
+			source: "---\ndefaults:\n  Port: 80\n  Image:\n    Tag: latest\nrequired: [Name]\n" +
+				"output: '{{ .Name }}.yaml'\nmissingkey: zero\ndelims: '[[,]]'\n---\nbody\n",
+			wantBody: "body\n",
+			wantMatter: frontmatter.Matter{
+				Defaults: frontmatter.Defaults{"Port": 80, "Image": map[string]any{"Tag": "latest"}},
+				Required: frontmatter.RequiredKeys{"Name"},
+				Output:   "{{ .Name }}.yaml",
+				Missing:  "zero",
+				Delims:   "[[,]]",
+				Lines:    10,
+			},
+		},
+		{
+			name:       "CRLF line endings",
+			source:     "---\r\noutput: x\r\n---\r\nbody",
+			wantBody:   "body",
+			wantMatter: frontmatter.Matter{Output: "x", Lines: 3},
+		},
+		{
+			name:     "empty",
+			source:   "---\n---\nbody",
+			wantBody: "---\n---\nbody",
+		},
+		{
+			name:     "a comment-only YAML document",
+			source:   "---\n# header\n---\napiVersion: v1\n",
+			wantBody: "---\n# header\n---\napiVersion: v1\n",
+		},
+		{
+			name:     "a YAML document",
+			source:   "---\napiVersion: v1\n---\nkind: Pod\n",
+			wantBody: "---\napiVersion: v1\n---\nkind: Pod\n",
+		},
+		{
+			name:     "unclosed",
+			source:   "---\noutput: x\n",
+			wantBody: "---\noutput: x\n",
+		},
+		{
+			name:     "not YAML",
+			source:   "---\n{{ .Name }}\n---\n",
+			wantBody: "---\n{{ .Name }}\n---\n",
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			matter, body, err := frontmatter.Split([]byte(tc.source))
+			require.NoError(t, err)
+			assert.Equal(t, tc.wantMatter, matter)
+			assert.Equal(t, tc.wantBody, string(body))
+		})
+	}
+
+	_, _, err := frontmatter.Split([]byte("---\nrequired: {Name: true}\n---\n"))
+	require.ErrorIs(t, err, constants.ErrParseFrontMatter)
+}
+
+// TestMatterCheck pins required keys: dotted paths through nested maps, the
+// environment's included, each holding a non-nil value.
+func TestMatterCheck(t *testing.T) {
+	t.Parallel()
+	data := map[string]any{
+		"Name":  "web",
+		"Image": map[string]any{"Tag": "latest", "Digest": nil},
+		"env":   map[string]string{"HOME": "/home"},
+	}
+	matter := frontmatter.Matter{Required: frontmatter.RequiredKeys{"Name", "Image.Tag", "env.HOME"}}
+	require.NoError(t, matter.Check(data))
+
+	for _, key := range []string{"Port", "Image.Digest", "Name.First", "env.USER"} {
+		matter := frontmatter.Matter{Required: frontmatter.RequiredKeys{key}}
+		require.ErrorIs(t, matter.Check(data), constants.ErrRequiredKey, key)
+	}
+}
+
+// TestMatterShift pins that a failure in the body moves down by the front
+// matter's lines, and one located anywhere else stays put.
+func TestMatterShift(t *testing.T) {
+	t.Parallel()
+	matter := frontmatter.Matter{Lines: 4}
+	body := &constants.TemplateError{Cause: constants.ErrExecuteTemplate, Template: "t.tmpl", Line: 2}
+	library := &constants.TemplateError{Cause: constants.ErrExecuteTemplate, Template: "lib.tmpl", Line: 2}
+
+	require.ErrorIs(t, matter.Shift("t.tmpl", body), constants.ErrExecuteTemplate)
+	assert.Equal(t, 6, body.Line)
+	require.ErrorIs(t, matter.Shift("t.tmpl", library), constants.ErrExecuteTemplate)
+	assert.Equal(t, 2, library.Line)
+}
@@ -0,0 +1,107 @@
+// Package golden reads the golden-file test cases of a template suite and
+// compares what they render with what they expect. A case is a directory
+// holding the expected output, expect.txt, and optionally an examples.sh
+// script whose `renderizer …` lines are the invocations the output is the
+// concatenation of, in order:
+//
+//	#!/usr/bin/env bash
+//	name=$(basename "${PWD}")
+//	export GREETING=hello
+//	renderizer
+//	renderizer "${name}.txt.tmpl" --name=World
+//
+// The script is read, never run: each invocation line is split into words as
+// the shell splits them, quotes and backslashes included, with $name and
+// ${name} expanding to the name of the case's directory, and each `export`
+// line sets a variable of the environment every invocation sees. Any other
+// line is ignored. A case without a script renders once, without arguments.
+// It is an implementation package: pure, with no IO and no CLI knowledge.
+package golden
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+
+	"github.com/gomatic/renderizer/internal/constants"
+)
+
+// The files of a case.
+const (
+	ExpectFile = "expect.txt"
+	ScriptFile = "examples.sh"
+)
+
+// The words the script's lines are recognized by.
+const (
+	command = "renderizer"
+	export  = "export"
+)
+
+// noNewline marks a last line that does not end in a newline, as diff marks it.
+const noNewline = `\ No newline at end of file`
+
+// Script is what a case's script runs: the arguments of each invocation, in
+// order, and the environment it exports to them, as NAME=VALUE entries.
+type Script struct {
+	Invocations [][]string
+	Environ     []string
+}
+
+// Invocation is one render of a case: the directory it runs in, its
+// arguments, and its environment.
+type Invocation struct {
+	Dir     string
+	Args    []string
+	Environ []string
+}
+
+// DefaultScript is the script of a case without one: a single invocation,
+// without arguments.
+func DefaultScript() Script {
+	return Script{Invocations: [][]string{{}}}
+}
+
+// ParseScript returns the script of content, the examples.sh of the case named
+// name. A line whose quotes are never closed fails with ErrParseScript.
+func ParseScript(name string, content []byte) (Script, error) {
+	var script Script
+	for number, line := range strings.Split(string(content), "\n") {
+		words, err := split(strings.TrimSpace(line), name)
+		if err != nil {
+			return Script{}, constants.ErrParseScript.With(err, "line", number+1)
+		}
+		switch {
+		case len(words) == 0:
+		case words[0] == command:
+			script.Invocations = append(script.Invocations, words[1:])
+		case words[0] == export:
+			script.Environ = append(script.Environ, words[1:]...)
+		}
+	}
+	return script, nil
+}
+
+// Diff returns the unified diff of the expected output of the case named name
+// to its rendered output.
+func Diff(name string, expected, rendered []byte) string {
+	diff, _ := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        lines(expected),
+		B:        lines(rendered),
+		FromFile: name + "/" + ExpectFile,
+		ToFile:   name + " (rendered)",
+		Context:  3,
+	})
+	return diff
+}
+
+// lines returns the lines of text, each ending in a newline, its last marked
+// as diff marks it when text does not end in one.
+func lines(text []byte) []string {
+	split := slices.Collect(strings.Lines(string(text)))
+	if last := len(split) - 1; last >= 0 && !strings.HasSuffix(split[last], "\n") {
+		split[last] += "\n" + noNewline + "\n"
+	}
+	return split
+}
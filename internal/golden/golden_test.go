@@ -0,0 +1,53 @@
+package golden_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gomatic/renderizer/internal/constants"
+	"github.com/gomatic/renderizer/internal/golden"
+)
+
+// TestParseScript pins that invocation lines split as the shell splits them,
+// with $name expanding to the case's name outside single quotes, that export
+// lines build the environment, and that every other line is ignored.
+func TestParseScript(t *testing.T) {
+	t.Parallel()
+	script, err := golden.ParseScript("pod", []byte(`#!/usr/bin/env bash
+name=$(basename "${PWD}")
+export GREETING=hello COLOR="dark blue"
+renderizer
+  renderizer "${name}.yaml.tmpl" --settings=".$name.yaml" # a comment
+renderizer '$name' "say \"hi\"" a\ b --tag=#1
+make all
+`))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"GREETING=hello", "COLOR=dark blue"}, script.Environ)
+	assert.Equal(t, [][]string{
+		{},
+		{"pod.yaml.tmpl", "--settings=.pod.yaml"},
+		{"$name", `say "hi"`, "a b", "--tag=#1"},
+	}, script.Invocations)
+}
+
+func TestParseScriptUnterminatedQuote(t *testing.T) {
+	t.Parallel()
+	_, err := golden.ParseScript("pod", []byte("renderizer\nrenderizer \"pod.yaml.tmpl\n"))
+	require.ErrorIs(t, err, constants.ErrParseScript)
+	assert.Contains(t, err.Error(), "line 2")
+}
+
+func TestDefaultScript(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, [][]string{{}}, golden.DefaultScript().Invocations)
+}
+
+func TestDiff(t *testing.T) {
+	t.Parallel()
+	diff := golden.Diff("pod", []byte("a\nb\nc\n"), []byte("a\nB\nc\n"))
+	assert.Equal(t, "--- pod/expect.txt\n+++ pod (rendered)\n@@ -1,3 +1,3 @@\n a\n-b\n+B\n c\n", diff)
+	assert.Empty(t, golden.Diff("pod", []byte("a\n"), []byte("a\n")))
+	assert.Contains(t, golden.Diff("pod", []byte("a\n"), []byte("a")), "+a\n\\ No newline at end of file\n")
+}
@@ -0,0 +1,126 @@
+package golden
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// nameVariable matches the variable a script line may use for the name of
+// its case's directory.
+var nameVariable = regexp.MustCompile(`\$\{name\}|\$name\b`)
+
+// split returns the words of line, a line of the script of the case named
+// name, as the shell splits them: at unquoted blanks, up to an unquoted #
+// starting a word, with quotes removed and $name expanded outside single
+// quotes.
+func split(line, name string) ([]string, error) {
+	s := splitter{name: name}
+	for _, r := range line {
+		if isComment := s.scan(r); isComment {
+			break
+		}
+	}
+	if s.quote != 0 || s.isEscaped {
+		return nil, errors.New("unterminated quote or escape")
+	}
+	s.end()
+	return s.words, nil
+}
+
+// splitter splits a line into words, a rune at a time. The text of the word
+// being split that expands waits in pending until text that does not.
+type splitter struct {
+	name      string
+	words     []string
+	word      strings.Builder
+	pending   strings.Builder
+	quote     rune
+	isWord    bool
+	isEscaped bool
+}
+
+// scan splits at r, reporting whether r starts a comment.
+func (s *splitter) scan(r rune) bool {
+	switch {
+	case s.isEscaped:
+		s.isEscaped = false
+		s.literal(r)
+	case s.quote == '\'':
+		s.singleQuoted(r)
+	case s.quote == '"':
+		s.doubleQuoted(r)
+	default:
+		return s.unquoted(r)
+	}
+	return false
+}
+
+// singleQuoted splits at r inside single quotes, where nothing expands.
+func (s *splitter) singleQuoted(r rune) {
+	if r == '\'' {
+		s.quote = 0
+		return
+	}
+	s.literal(r)
+}
+
+// doubleQuoted splits at r inside double quotes.
+func (s *splitter) doubleQuoted(r rune) {
+	switch r {
+	case '"':
+		s.quote = 0
+	case '\\':
+		s.isEscaped = true
+	default:
+		s.expandable(r)
+	}
+}
+
+// unquoted splits at r outside quotes, reporting whether r starts a comment.
+func (s *splitter) unquoted(r rune) bool {
+	switch {
+	case r == '#' && !s.isWord:
+		return true
+	case unicode.IsSpace(r):
+		s.end()
+	case r == '\'' || r == '"':
+		s.quote, s.isWord = r, true
+	case r == '\\':
+		s.isEscaped, s.isWord = true, true
+	default:
+		s.expandable(r)
+	}
+	return false
+}
+
+// literal adds r to the word as it is.
+func (s *splitter) literal(r rune) {
+	s.flush()
+	s.word.WriteRune(r)
+	s.isWord = true
+}
+
+// expandable adds r to the word, expanding any $name it completes.
+func (s *splitter) expandable(r rune) {
+	s.pending.WriteRune(r)
+	s.isWord = true
+}
+
+// flush adds the pending text to the word, expanded.
+func (s *splitter) flush() {
+	s.word.WriteString(nameVariable.ReplaceAllLiteralString(s.pending.String(), s.name))
+	s.pending.Reset()
+}
+
+// end ends the word, if one has begun.
+func (s *splitter) end() {
+	if !s.isWord {
+		return
+	}
+	s.flush()
+	s.words = append(s.words, s.word.String())
+	s.word.Reset()
+	s.isWord = false
+}
@@ -9,6 +9,7 @@ import (
 
 	"github.com/gomatic/renderizer/internal/constants"
 	"github.com/gomatic/renderizer/internal/inspect"
+	tmpl "github.com/gomatic/renderizer/internal/template"
 )
 
 // FuzzAnalyzeInfersASoundModel pins Analyze's contract on arbitrary template
@@ -32,13 +33,13 @@ func FuzzAnalyzeInfersASoundModel(f *testing.F) {
 	}
 	funcs := template.FuncMap{"noSuchFunc": func(any) string { return "" }}
 	f.Fuzz(func(t *testing.T, source []byte) {
-		model, err := inspect.Analyze(funcs, "fuzz", source)
+		model, err := inspect.Analyze(funcs, tmpl.Syntax{}, "fuzz", source)
 		if err != nil {
 			require.ErrorIs(t, err, constants.ErrParseTemplate, "the only failure is the parse sentinel")
 			require.Nil(t, model.Fields, "a failed analysis must return the zero model")
 			return
 		}
-		again, err := inspect.Analyze(funcs, "fuzz", source)
+		again, err := inspect.Analyze(funcs, tmpl.Syntax{}, "fuzz", source)
 		require.NoError(t, err, "a successful analysis must succeed again")
 		require.Equal(t, model, again, "analysis is deterministic for the same source")
 
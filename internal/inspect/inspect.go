@@ -14,7 +14,7 @@ import (
 
 	"gopkg.in/yaml.v3"
 
-	"github.com/gomatic/renderizer/internal/constants"
+	tmpl "github.com/gomatic/renderizer/internal/template"
 )
 
 type (
@@ -38,18 +38,18 @@ type (
 	}
 )
 
-// Analyze parses source and infers the data model it reads. funcs must contain
-// every function the template calls so parsing succeeds; missingkey=zero keeps
-// analysis independent of the data.
-func Analyze(funcs template.FuncMap, name Name, source []byte) (Model, error) {
-	parsed, err := template.New(string(name)).Funcs(funcs).Option("missingkey=zero").Parse(string(source))
+// Analyze parses source in syntax and infers the data model it reads. funcs
+// must contain every function the template calls so parsing succeeds;
+// missingkey=zero keeps analysis independent of the data.
+func Analyze(funcs template.FuncMap, syntax tmpl.Syntax, name Name, source []byte) (Model, error) {
+	parsed, err := tmpl.Parse(funcs, tmpl.MissingKeyZero, syntax, tmpl.Name(name), source)
 	if err != nil {
-		return Model{}, constants.ErrParseTemplate.With(err)
+		return Model{}, err
 	}
 	model := Model{Fields: Fields{}}
 	root := scope{root: model.Fields, dot: model.Fields, vars: map[string]Fields{}}
-	if parsed.Tree != nil {
-		walk(parsed.Root, root)
+	if body := parsed.Body(); body != nil {
+		walk(body.Root, root)
 	}
 	return model, nil
 }
@@ -58,14 +58,40 @@ func Analyze(funcs template.FuncMap, name Name, source []byte) (Model, error) {
 // as empty strings, lists as a single example element, and nested fields as
 // maps. An empty model renders as an explanatory comment.
 func Skeleton(model Model) []byte {
-	if len(model.Fields) == 0 {
+	return SkeletonWith(model, nil)
+}
+
+// SkeletonWith renders the model as Skeleton does, with the defaults a
+// template declares in place of the placeholders for their names, and
+// alongside them when the template does not read them.
+func SkeletonWith(model Model, defaults map[string]any) []byte {
+	if len(model.Fields) == 0 && len(defaults) == 0 {
 		return []byte("# template requires no input data\n")
 	}
+	skeleton := map[string]any{}
+	if placeholder, isMap := build(model.Fields).(map[string]any); isMap {
+		skeleton = placeholder
+	}
+	fill(skeleton, defaults)
 	// yaml.Marshal of plain map/slice/string values is infallible.
-	out, _ := yaml.Marshal(build(model.Fields))
+	out, _ := yaml.Marshal(skeleton)
 	return out
 }
 
+// fill sets each of defaults in skeleton, merging the maps both hold rather
+// than replacing the skeleton's.
+func fill(skeleton, defaults map[string]any) {
+	for name, value := range defaults {
+		nested, isMap := skeleton[name].(map[string]any)
+		defaultMap, defaultIsMap := value.(map[string]any)
+		if isMap && defaultIsMap {
+			fill(nested, defaultMap)
+			continue
+		}
+		skeleton[name] = value
+	}
+}
+
 // build turns a field set into a placeholder value tree.
 func build(fields Fields) any {
 	if len(fields) == 0 {
@@ -14,7 +14,7 @@ import (
 // skeleton analyzes source and returns its YAML data-model skeleton.
 func skeleton(t *testing.T, source string) string {
 	t.Helper()
-	model, err := inspect.Analyze(template.Funcs(false), "test", []byte(source))
+	model, err := inspect.Analyze(template.Funcs(false), template.Syntax{}, "test", []byte(source))
 	require.NoError(t, err)
 	return string(inspect.Skeleton(model))
 }
@@ -140,7 +140,7 @@ func TestAnalyzeEmptyModel(t *testing.T) {
 
 func TestAnalyzeListFlag(t *testing.T) {
 	t.Parallel()
-	model, err := inspect.Analyze(template.Funcs(false), "test", []byte("{{range .Items}}{{.Name}}{{end}}"))
+	model, err := inspect.Analyze(template.Funcs(false), template.Syntax{}, "test", []byte("{{range .Items}}{{.Name}}{{end}}"))
 	require.NoError(t, err)
 	items := model.Fields["Items"]
 	require.NotNil(t, items)
@@ -150,6 +150,25 @@ func TestAnalyzeListFlag(t *testing.T) {
 
 func TestAnalyzeParseError(t *testing.T) {
 	t.Parallel()
-	_, err := inspect.Analyze(template.Funcs(false), "test", []byte("{{.Unclosed"))
+	_, err := inspect.Analyze(template.Funcs(false), template.Syntax{}, "test", []byte("{{.Unclosed"))
 	require.ErrorIs(t, err, constants.ErrParseTemplate)
 }
+
+func TestAnalyzeWithDelims(t *testing.T) {
+	t.Parallel()
+	model, err := inspect.Analyze(template.Funcs(false), template.Syntax{Delims: template.Delims{Left: "[[", Right: "]]"}}, "test",
+		[]byte("[[ .Name ]] {{ .Other }}"))
+	require.NoError(t, err)
+	assert.Contains(t, model.Fields, "Name")
+	assert.NotContains(t, model.Fields, "Other")
+}
+
+func TestAnalyzeHTML(t *testing.T) {
+	t.Parallel()
+	model, err := inspect.Analyze(template.Funcs(false), template.Syntax{IsHTML: true}, "test",
+		[]byte(`<a href="{{ .Url }}">{{ range .Items }}<i>{{ .Name }}</i>{{ end }}</a>`))
+	require.NoError(t, err)
+	assert.Contains(t, model.Fields, "Url")
+	require.Contains(t, model.Fields, "Items")
+	assert.True(t, model.Fields["Items"].IsList)
+}
@@ -17,7 +17,7 @@ import (
 // is a claim about nothing.
 func analyzed(t *testing.T, source string) string {
 	t.Helper()
-	model, err := inspect.Analyze(template.Funcs(false), "test", []byte(source))
+	model, err := inspect.Analyze(template.Funcs(false), template.Syntax{}, "test", []byte(source))
 	require.NoError(t, err)
 	return string(inspect.Skeleton(model))
 }
@@ -0,0 +1,223 @@
+// Package provenance records where each leaf of a merged variable context came
+// from — a settings file line, a command-line argument, the environment, or a
+// renderizer flag — and renders that record beside the final values. It is an
+// implementation package: it knows nothing about precedence, which its caller
+// expresses by the order in which it records origins, highest priority first.
+package provenance
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Kind names the layer an origin belongs to. Appended origins only join one
+// another within the same layer: a command-line list and a settings list are
+// never combined, so one shadows the other.
+type Kind string
+
+// The layers a context value can come from.
+const (
+	KindArgument    Kind = "argument"
+	KindEnvironment Kind = "environment"
+	KindFlag        Kind = "flag"
+	KindSettings    Kind = "settings"
+)
+
+// Origin is one source that supplied a value: its layer and a human-readable
+// location within it (file and line, argument index, or variable name).
+type Origin struct {
+	Kind     Kind
+	Location string
+}
+
+// String renders the origin as "<kind> <location>".
+func (o Origin) String() string {
+	return string(o.Kind) + " " + o.Location
+}
+
+// Argument is the origin of a command-line assignment: its position among the
+// assignments and the token itself.
+func Argument(index int, token string) Origin {
+	return Origin{Kind: KindArgument, Location: fmt.Sprintf("#%d (%s)", index, token)}
+}
+
+// Setting is the origin of a value defined in a settings file at line.
+func Setting(path string, line int) Origin {
+	return Origin{Kind: KindSettings, Location: fmt.Sprintf("%s:%d", path, line)}
+}
+
+// Environment is the origin of a value read from the named environment
+// variable.
+func Environment(name string) Origin {
+	return Origin{Kind: KindEnvironment, Location: name}
+}
+
+// Flag is the origin of a value renderizer derives from one of its own flags.
+func Flag(name string) Origin {
+	return Origin{Kind: KindFlag, Location: "--" + name}
+}
+
+// effect is how a recorded origin combines with the origins before it from the
+// same layer.
+type effect int
+
+const (
+	// keeps: the origin set the value only if nothing earlier did.
+	keeps effect = iota
+	// appends: the origin contributed list elements alongside earlier ones.
+	appends
+	// replaces: the origin superseded the earlier ones.
+	replaces
+)
+
+// claim is one recorded origin for a path and how it combined.
+type claim struct {
+	origin Origin
+	effect effect
+}
+
+// Trace accumulates, per leaf path, every origin that set it. Record layers
+// highest priority first, and origins within a layer in the order they were
+// merged; an origin never displaces one from a higher-priority layer. The zero
+// value is not usable; construct one with NewTrace.
+type Trace struct {
+	claims map[string][]claim
+}
+
+// NewTrace returns an empty trace.
+func NewTrace() Trace {
+	return Trace{claims: map[string][]claim{}}
+}
+
+// Claim records that origin set path only where nothing earlier had: the first
+// claim wins and later ones are shadowed.
+func (t Trace) Claim(path []string, origin Origin) {
+	t.record(path, claim{origin: origin, effect: keeps})
+}
+
+// Append records that origin contributed elements to the list at path, joining
+// the earlier origins from the same layer.
+func (t Trace) Append(path []string, origin Origin) {
+	t.record(path, claim{origin: origin, effect: appends})
+}
+
+// Replace records that origin superseded the earlier origins from its layer,
+// which it shadows.
+func (t Trace) Replace(path []string, origin Origin) {
+	t.record(path, claim{origin: origin, effect: replaces})
+}
+
+// record appends c to the claims on path.
+func (t Trace) record(path []string, c claim) {
+	key := Key(path)
+	t.claims[key] = append(t.claims[key], c)
+}
+
+// Key joins path into the dotted form used to index and print leaves.
+func Key(path []string) string {
+	return strings.Join(path, ".")
+}
+
+// Entry is one leaf of the explained context: its value, the origins that
+// supplied it, and the lower-priority origins it shadowed.
+type Entry struct {
+	Value    any
+	Path     string
+	From     []Origin
+	Shadowed []Origin
+}
+
+// Entries walks data and pairs every leaf with its recorded origins, sorted by
+// path. Lists are leaves: their elements are not individually attributed.
+func (t Trace) Entries(data map[string]any) []Entry {
+	var entries []Entry
+	collect(data, nil, func(path []string, value any) {
+		from, shadowed := t.split(Key(path))
+		entries = append(entries, Entry{Path: Key(path), Value: value, From: from, Shadowed: shadowed})
+	})
+	slices.SortFunc(entries, func(a, b Entry) int { return strings.Compare(a.Path, b.Path) })
+	return entries
+}
+
+// split replays a path's claims into the origins that supplied the final value
+// and the ones shadowed along the way. The first claim always supplies it; a
+// later claim from a different layer is always shadowed.
+func (t Trace) split(key string) ([]Origin, []Origin) {
+	var from, shadowed []Origin
+	for _, c := range t.claims[key] {
+		switch {
+		case len(from) == 0:
+			from = []Origin{c.origin}
+		case c.origin.Kind != from[0].Kind || c.effect == keeps:
+			shadowed = append(shadowed, c.origin)
+		case c.effect == appends:
+			from = append(from, c.origin)
+		default:
+			shadowed = append(shadowed, from...)
+			from = []Origin{c.origin}
+		}
+	}
+	return from, shadowed
+}
+
+// collect calls visit for every non-map value under data, descending maps —
+// including the string map the environment is bound as.
+func collect(data map[string]any, prefix []string, visit func([]string, any)) {
+	for key, value := range data {
+		collectValue(append(slices.Clone(prefix), key), value, visit)
+	}
+}
+
+// collectValue visits value at path, or the leaves beneath it when it is a
+// non-empty map.
+func collectValue(path []string, value any, visit func([]string, any)) {
+	switch nested := value.(type) {
+	case map[string]any:
+		if len(nested) > 0 {
+			collect(nested, path, visit)
+			return
+		}
+	case map[string]string:
+		for name, text := range nested {
+			visit(append(slices.Clone(path), name), text)
+		}
+		return
+	}
+	visit(path, value)
+}
+
+// Explain renders entries one per line as
+// "<path> = <value>  # from <origins>[; shadows <origins>]".
+func Explain(entries []Entry) []byte {
+	var out strings.Builder
+	for _, entry := range entries {
+		fmt.Fprintf(&out, "%s = %s  # from %s", entry.Path, format(entry.Value), join(entry.From))
+		if len(entry.Shadowed) > 0 {
+			fmt.Fprintf(&out, "; shadows %s", join(entry.Shadowed))
+		}
+		out.WriteByte('\n')
+	}
+	return []byte(out.String())
+}
+
+// format renders a leaf value, quoting strings so empty and padded values
+// stay visible.
+func format(value any) string {
+	if text, ok := value.(string); ok {
+		return fmt.Sprintf("%q", text)
+	}
+	return fmt.Sprintf("%v", value)
+}
+
+// join renders origins comma-separated, or "unknown" when there are none.
+func join(origins []Origin) string {
+	if len(origins) == 0 {
+		return "unknown"
+	}
+	parts := make([]string, len(origins))
+	for i, origin := range origins {
+		parts[i] = origin.String()
+	}
+	return strings.Join(parts, ", ")
+}
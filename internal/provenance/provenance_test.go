@@ -0,0 +1,114 @@
+package provenance_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/gomatic/renderizer/internal/provenance"
+)
+
+// TestEntriesAttributeTheWinnerAndListWhatItShadowed names the trace's claim:
+// the first origin recorded for a path supplied its value, and every later one
+// was shadowed. This is the answer --explain exists to give; getting the order
+// backwards would blame the file a user overrode for the value they see.
+func TestEntriesAttributeTheWinnerAndListWhatItShadowed(t *testing.T) {
+	t.Parallel()
+	trace := provenance.NewTrace()
+	trace.Append([]string{"Name"}, provenance.Argument(0, "--name=cli"))
+	trace.Claim([]string{"Name"}, provenance.Setting("s.yaml", 1))
+	trace.Claim([]string{"nested", "deep"}, provenance.Setting("s.yaml", 3))
+
+	entries := trace.Entries(map[string]any{
+		"Name":   "cli",
+		"nested": map[string]any{"deep": int64(2)},
+	})
+
+	assert.Equal(t, []provenance.Entry{
+		{
+			Path:     "Name",
+			Value:    "cli",
+			From:     []provenance.Origin{provenance.Argument(0, "--name=cli")},
+			Shadowed: []provenance.Origin{provenance.Setting("s.yaml", 1)},
+		},
+		{
+			Path:  "nested.deep",
+			Value: int64(2),
+			From:  []provenance.Origin{provenance.Setting("s.yaml", 3)},
+		},
+	}, entries)
+}
+
+// TestEntriesJoinAppendsOnlyWithinOneLayer pins the append rule: lists from two
+// settings files are concatenated, so both files supplied the value, but a
+// settings list never joins a command-line list — the command line replaces it.
+func TestEntriesJoinAppendsOnlyWithinOneLayer(t *testing.T) {
+	t.Parallel()
+	settingsOnly := provenance.NewTrace()
+	settingsOnly.Append([]string{"items"}, provenance.Setting("a.yaml", 2))
+	settingsOnly.Append([]string{"items"}, provenance.Setting("b.yaml", 1))
+
+	entries := settingsOnly.Entries(map[string]any{"items": []any{"one", "two"}})
+	assert.Len(t, entries[0].From, 2, "both files appended to the list")
+	assert.Empty(t, entries[0].Shadowed)
+
+	mixed := provenance.NewTrace()
+	mixed.Append([]string{"items"}, provenance.Argument(0, "--items=x"))
+	mixed.Append([]string{"items"}, provenance.Setting("a.yaml", 2))
+
+	entries = mixed.Entries(map[string]any{"items": "x"})
+	assert.Equal(t, []provenance.Origin{provenance.Argument(0, "--items=x")}, entries[0].From)
+	assert.Equal(t, []provenance.Origin{provenance.Setting("a.yaml", 2)}, entries[0].Shadowed)
+}
+
+// TestEntriesReplaceSupersedesOnlyItsOwnLayer pins Replace: an overriding
+// settings file supersedes the earlier file, but never a command-line value.
+func TestEntriesReplaceSupersedesOnlyItsOwnLayer(t *testing.T) {
+	t.Parallel()
+	settingsOnly := provenance.NewTrace()
+	settingsOnly.Replace([]string{"name"}, provenance.Setting("a.yaml", 1))
+	settingsOnly.Replace([]string{"name"}, provenance.Setting("b.yaml", 1))
+
+	entries := settingsOnly.Entries(map[string]any{"name": "b"})
+	assert.Equal(t, []provenance.Origin{provenance.Setting("b.yaml", 1)}, entries[0].From)
+	assert.Equal(t, []provenance.Origin{provenance.Setting("a.yaml", 1)}, entries[0].Shadowed)
+
+	withArgument := provenance.NewTrace()
+	withArgument.Append([]string{"name"}, provenance.Argument(0, "--name=cli"))
+	withArgument.Replace([]string{"name"}, provenance.Setting("a.yaml", 1))
+
+	entries = withArgument.Entries(map[string]any{"name": "cli"})
+	assert.Equal(t, []provenance.Origin{provenance.Argument(0, "--name=cli")}, entries[0].From)
+	assert.Equal(t, []provenance.Origin{provenance.Setting("a.yaml", 1)}, entries[0].Shadowed)
+}
+
+func TestEntriesDescendIntoTheEnvironmentMap(t *testing.T) {
+	t.Parallel()
+	trace := provenance.NewTrace()
+	trace.Claim([]string{"env", "USER"}, provenance.Environment("USER"))
+
+	entries := trace.Entries(map[string]any{"env": map[string]string{"USER": "alice"}})
+
+	assert.Equal(t, []provenance.Entry{{
+		Path:  "env.USER",
+		Value: "alice",
+		From:  []provenance.Origin{provenance.Environment("USER")},
+	}}, entries)
+}
+
+func TestExplain(t *testing.T) {
+	t.Parallel()
+	out := provenance.Explain([]provenance.Entry{
+		{
+			Path:     "Name",
+			Value:    "cli",
+			From:     []provenance.Origin{provenance.Argument(0, "--name=cli")},
+			Shadowed: []provenance.Origin{provenance.Setting("s.yaml", 1)},
+		},
+		{Path: "Count", Value: int64(3)},
+	})
+	assert.Equal(t,
+		"Name = \"cli\"  # from argument #0 (--name=cli); shadows settings s.yaml:1\n"+
+			"Count = 3  # from unknown\n",
+		string(out))
+}
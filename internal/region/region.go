@@ -0,0 +1,133 @@
+// Package region finds the generated regions of a mostly hand-written file
+// and replaces them, leaving every byte outside them as it was. A region runs
+// from a line holding a begin marker to the next line holding an end marker,
+// whatever comment syntax surrounds them:
+//
+//	# renderizer:begin docs/flags.tmpl
+//	…generated…
+//	# renderizer:end
+//
+// The begin marker names the template that renders the region or, after
+// "inline", holds the template itself:
+//
+//	<!-- renderizer:begin inline {{ .Version }} -->
+//
+// It is an implementation package: pure, with no IO and no CLI knowledge.
+package region
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/gomatic/renderizer/internal/constants"
+)
+
+// The markers, and the prefix of a begin marker's inline template.
+const (
+	beginMarker  = "renderizer:begin"
+	endMarker    = "renderizer:end"
+	inlinePrefix = "inline "
+)
+
+// closers are the comment closers a begin marker's line may end with, which
+// are not part of what it names.
+var closers = []string{"-->", "*/"}
+
+// Region is one generated region of a file.
+type Region struct {
+	// Template is the path of the template rendering the region or, when
+	// IsInline, the template's source.
+	Template string
+	// Start and End are the offsets of the region's content: from the line
+	// after the begin marker up to the end marker's line.
+	Start, End int
+	// Line is the one-based line of the begin marker.
+	Line     int
+	IsInline bool
+}
+
+// Find returns the regions of content, in order. A begin marker naming
+// nothing, one inside a region, or an end marker outside one fails with
+// ErrParseMarkers, as does a region never ended.
+func Find(content []byte) ([]Region, error) {
+	var found finder
+	offset := 0
+	for number, line := range bytes.SplitAfter(content, []byte("\n")) {
+		if err := found.scan(string(line), number+1, offset, offset+len(line)); err != nil {
+			return nil, err
+		}
+		offset += len(line)
+	}
+	if found.open != nil {
+		return nil, constants.ErrParseMarkers.With(nil, "line", found.open.Line, "begins a region never ended")
+	}
+	return found.regions, nil
+}
+
+// finder collects the regions of the lines it scans, and the one they are in.
+type finder struct {
+	open    *Region
+	regions []Region
+}
+
+// scan scans the number-th line, from offset start to end.
+func (f *finder) scan(line string, number, start, end int) error {
+	switch {
+	case strings.Contains(line, beginMarker) && f.open != nil:
+		return constants.ErrParseMarkers.With(nil, "line", number, "begins a region inside another")
+	case strings.Contains(line, beginMarker):
+		begun, err := begin(line, number, end)
+		f.open = &begun
+		return err
+	case strings.Contains(line, endMarker) && f.open == nil:
+		return constants.ErrParseMarkers.With(nil, "line", number, "ends a region never begun")
+	case strings.Contains(line, endMarker):
+		f.open.End = start
+		f.regions = append(f.regions, *f.open)
+		f.open = nil
+	}
+	return nil
+}
+
+// begin returns the region the begin marker on line, the number-th, starts at
+// start.
+func begin(line string, number, start int) (Region, error) {
+	_, named, _ := strings.Cut(line, beginMarker)
+	named = strings.TrimSpace(named)
+	for _, closer := range closers {
+		named = strings.TrimSpace(strings.TrimSuffix(named, closer))
+	}
+	if named == "" {
+		return Region{}, constants.ErrParseMarkers.With(nil, "line", number, "begins a region naming no template")
+	}
+	source, isInline := strings.CutPrefix(named, inlinePrefix)
+	return Region{Template: source, Start: start, Line: number, IsInline: isInline}, nil
+}
+
+// IsCurrent reports whether the region of content already holds rendered.
+func (r Region) IsCurrent(content, rendered []byte) bool {
+	return bytes.Equal(content[r.Start:r.End], terminated(rendered))
+}
+
+// Replace returns content with the content of each of regions replaced by the
+// corresponding rendered content.
+func Replace(content []byte, regions []Region, rendered [][]byte) []byte {
+	var out bytes.Buffer
+	previous := 0
+	for i, region := range regions {
+		out.Write(content[previous:region.Start])
+		out.Write(terminated(rendered[i]))
+		previous = region.End
+	}
+	out.Write(content[previous:])
+	return out.Bytes()
+}
+
+// terminated returns rendered newline terminated, unless it is empty, so the
+// end marker keeps its own line.
+func terminated(rendered []byte) []byte {
+	if len(rendered) == 0 || bytes.HasSuffix(rendered, []byte("\n")) {
+		return rendered
+	}
+	return append(rendered[:len(rendered):len(rendered)], '\n')
+}
@@ -0,0 +1,51 @@
+package region_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gomatic/renderizer/internal/constants"
+	"github.com/gomatic/renderizer/internal/region"
+)
+
+// TestFindAndReplace pins that regions are found whatever comment syntax
+// wraps their markers, and that replacing them rewrites only their content:
+// every other byte, the markers included, is kept as it was.
+func TestFindAndReplace(t *testing.T) {
+	t.Parallel()
+	content := []byte("# Title\r\n# renderizer:begin docs/flags.tmpl\nold\n# renderizer:end\n" +
+		"text\n<!-- renderizer:begin inline {{ .Version }} -->\n<!-- renderizer:end -->\ntail")
+
+	regions, err := region.Find(content)
+	require.NoError(t, err)
+	require.Len(t, regions, 2)
+	assert.Equal(t, "docs/flags.tmpl", regions[0].Template)
+	assert.False(t, regions[0].IsInline)
+	assert.Equal(t, 2, regions[0].Line)
+	assert.Equal(t, "old\n", string(content[regions[0].Start:regions[0].End]))
+	assert.Equal(t, "{{ .Version }}", regions[1].Template)
+	assert.True(t, regions[1].IsInline)
+	assert.Equal(t, 6, regions[1].Line)
+
+	assert.True(t, regions[0].IsCurrent(content, []byte("old")), "a region is current without the newline")
+	assert.False(t, regions[1].IsCurrent(content, []byte("1.0")))
+
+	rendered := region.Replace(content, regions, [][]byte{[]byte("new\nlines\n"), []byte("1.0")})
+	assert.Equal(t, "# Title\r\n# renderizer:begin docs/flags.tmpl\nnew\nlines\n# renderizer:end\n"+
+		"text\n<!-- renderizer:begin inline {{ .Version }} -->\n1.0\n<!-- renderizer:end -->\ntail", string(rendered))
+}
+
+func TestFindRejectsUnbalancedMarkers(t *testing.T) {
+	t.Parallel()
+	for _, content := range []string{
+		"# renderizer:begin a\n",
+		"# renderizer:end\n",
+		"# renderizer:begin a\n# renderizer:begin b\n# renderizer:end\n",
+		"# renderizer:begin -->\n# renderizer:end\n",
+	} {
+		_, err := region.Find([]byte(content))
+		require.ErrorIs(t, err, constants.ErrParseMarkers, content)
+	}
+}
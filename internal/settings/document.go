@@ -0,0 +1,148 @@
+package settings
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/gomatic/renderizer/internal/constants"
+	"github.com/gomatic/renderizer/internal/environment"
+)
+
+// Reading a settings file into a YAML node tree: `!include` tags are replaced
+// by the files they name and `${VAR}` references in scalar values are replaced
+// by the environment, before the tree is decoded. Load and Origins both read
+// through here, so what is explained is exactly what was loaded.
+
+// includeTag marks a value to be replaced by the YAML document at its path,
+// resolved relative to the including file.
+const includeTag = "!include"
+
+// reference matches `${VAR}` and `${VAR:-default}`, and the escaped `$${…}`,
+// which stands for itself minus one dollar.
+var reference = regexp.MustCompile(`\$?\$\{([A-Za-z_][A-Za-z0-9_]*)(?::-([^}]*))?\}`)
+
+// document is one settings file's expanded node tree, with the file each
+// included subtree came from so its leaves are attributed to that file.
+type document struct {
+	files map[*yaml.Node]string
+	root  yaml.Node
+}
+
+// expander resolves includes through the injected reader and interpolates
+// against env, recording where each included subtree came from. A non-nil
+// render is applied to every file's text, included ones too, before it is
+// parsed.
+type expander struct {
+	read   ReadFile
+	render func(path string, text []byte) ([]byte, error)
+	env    environment.Variables
+	files  map[*yaml.Node]string
+}
+
+// parseDocument renders and parses data, read from path, and expands it.
+func (e expander) parseDocument(path string, data []byte) (document, error) {
+	data, err := e.text(path, data)
+	if err != nil {
+		return document{}, err
+	}
+	doc := document{files: map[*yaml.Node]string{}}
+	if err := yaml.Unmarshal(data, &doc.root); err != nil {
+		return document{}, constants.ErrParseSettings.With(err, path)
+	}
+	e.files = doc.files
+	if err := e.node(&doc.root, path, []string{path}); err != nil {
+		return document{}, err
+	}
+	return doc, nil
+}
+
+// text returns a file's text as it is to be parsed: rendered, when templated.
+func (e expander) text(path string, data []byte) ([]byte, error) {
+	if e.render == nil {
+		return data, nil
+	}
+	return e.render(path, data)
+}
+
+// node expands one node read from path: an include is replaced by its target,
+// a scalar is interpolated, and anything else has its values expanded. stack
+// holds the chain of files being included, to detect a cycle.
+func (e expander) node(node *yaml.Node, path string, stack []string) error {
+	if node.Tag == includeTag {
+		return e.include(node, path, stack)
+	}
+	if node.Kind == yaml.ScalarNode {
+		node.Value = interpolate(node.Value, e.env)
+		return nil
+	}
+	for _, child := range values(node) {
+		if err := e.node(child, path, stack); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// values returns the value nodes node holds: a mapping's values but not its
+// keys, which are names, or else all of its content.
+func values(node *yaml.Node) []*yaml.Node {
+	if node.Kind != yaml.MappingNode {
+		return node.Content
+	}
+	held := make([]*yaml.Node, 0, len(node.Content)/2)
+	for i := 1; i < len(node.Content); i += 2 {
+		held = append(held, node.Content[i])
+	}
+	return held
+}
+
+// include replaces node with the expanded document its value names.
+func (e expander) include(node *yaml.Node, from string, stack []string) error {
+	target := RelativeTo(from, interpolate(node.Value, e.env))
+	if slices.Contains(stack, target) {
+		return constants.ErrParseSettings.With(nil, from, "include cycle:", strings.Join(append(stack, target), " -> "))
+	}
+	data, err := e.read(target)
+	if err != nil {
+		return constants.ErrParseSettings.With(err, from, "include", target)
+	}
+	if data, err = e.text(target, data); err != nil {
+		return err
+	}
+	var included yaml.Node
+	if err := yaml.Unmarshal(data, &included); err != nil {
+		return constants.ErrParseSettings.With(err, target)
+	}
+	content := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Line: node.Line}
+	if len(included.Content) > 0 {
+		content = included.Content[0]
+	}
+	if err := e.node(content, target, append(slices.Clone(stack), target)); err != nil {
+		return err
+	}
+	*node = *content
+	e.files[node] = target
+	return nil
+}
+
+// interpolate replaces each `${VAR}` in text with its environment value, or
+// with the default of `${VAR:-default}` when VAR is unset or empty; an unset
+// VAR without a default becomes empty, as in the shell.
+func interpolate(text string, env environment.Variables) string {
+	if !strings.Contains(text, "${") {
+		return text
+	}
+	return reference.ReplaceAllStringFunc(text, func(match string) string {
+		if strings.HasPrefix(match, "$$") {
+			return match[1:]
+		}
+		parts := reference.FindStringSubmatch(match)
+		if value := env[parts[1]]; value != "" {
+			return value
+		}
+		return parts[2]
+	})
+}
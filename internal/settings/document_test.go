@@ -0,0 +1,108 @@
+package settings_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gomatic/renderizer/internal/constants"
+	"github.com/gomatic/renderizer/internal/environment"
+	"github.com/gomatic/renderizer/internal/settings"
+	"github.com/gomatic/renderizer/internal/variables"
+)
+
+func TestLoadIncludesResolveRelativeToTheIncludingFile(t *testing.T) {
+	t.Parallel()
+	read := reader(map[string]string{
+		"conf/app.yaml":           "name: app\nlabels: !include shared/labels.yaml\n",
+		"conf/shared/labels.yaml": "team: platform\nowner: !include owner.yaml\n",
+		"conf/shared/owner.yaml":  "alice\n",
+		"conf/empty.yaml":         "",
+		"conf/uses-empty.yaml":    "nothing: !include empty.yaml\n",
+	})
+
+	got, err := loader(read, variables.MergeAppend).Load([]settings.File{{Path: "conf/app.yaml"}})
+	require.NoError(t, err)
+	assert.Equal(t, variables.Context{
+		"name":   "app",
+		"labels": map[string]any{"team": "platform", "owner": "alice"},
+	}, got)
+
+	got, err = loader(read, variables.MergeAppend).Load([]settings.File{{Path: "conf/uses-empty.yaml"}})
+	require.NoError(t, err)
+	assert.Equal(t, variables.Context{"nothing": nil}, got)
+}
+
+// TestLoadIncludeFailuresNameTheOffendingFile pins include errors: each is a
+// settings parse error, and each message names the file at fault, because a
+// cycle or a typo three includes deep is otherwise a hunt.
+func TestLoadIncludeFailuresNameTheOffendingFile(t *testing.T) {
+	t.Parallel()
+	read := reader(map[string]string{
+		"a.yaml":       "x: !include b.yaml\n",
+		"b.yaml":       "y: !include a.yaml\n",
+		"missing.yaml": "x: !include nowhere.yaml\n",
+		"outer.yaml":   "x: !include broken.yaml\n",
+		"broken.yaml":  "::: not yaml :::",
+	})
+	for _, tc := range []struct {
+		file string
+		want string
+	}{
+		{file: "a.yaml", want: "b.yaml include cycle: a.yaml -> b.yaml -> a.yaml"},
+		{file: "missing.yaml", want: "missing.yaml include nowhere.yaml"},
+		{file: "outer.yaml", want: "broken.yaml"},
+	} {
+		t.Run(tc.file, func(t *testing.T) {
+			t.Parallel()
+			_, err := loader(read, variables.MergeAppend).Load([]settings.File{{Path: tc.file}})
+			require.ErrorIs(t, err, constants.ErrParseSettings)
+			assert.Contains(t, err.Error(), tc.want)
+		})
+	}
+}
+
+func TestLoadInterpolatesTheEnvironment(t *testing.T) {
+	t.Parallel()
+	read := reader(map[string]string{
+		"s.yaml": "image: ${REGISTRY}/app\nport: ${PORT:-8080}\nregion: ${REGION:-us}\n" +
+			"unset: ${UNSET}\nliteral: $${REGISTRY}\nfile: !include ${DIR}/inc.yaml\n",
+		"conf/inc.yaml": "value: included\n",
+	})
+	load := settings.Loader{
+		Read:        read,
+		Environment: environment.Variables{"REGISTRY": "ghcr.io", "REGION": "eu", "DIR": "conf"},
+		Format:      timeFormat,
+		Strategy:    variables.MergeAppend,
+		Inference:   true,
+	}
+
+	got, err := load.Load([]settings.File{{Path: "s.yaml"}})
+
+	require.NoError(t, err)
+	assert.Equal(t, variables.Context{
+		"image":   "ghcr.io/app",
+		"port":    int64(8080),
+		"region":  "eu",
+		"unset":   "",
+		"literal": "${REGISTRY}",
+		"file":    map[string]any{"value": "included"},
+	}, got)
+}
+
+func TestOriginsAttributeIncludedLeavesToTheirFile(t *testing.T) {
+	t.Parallel()
+	read := reader(map[string]string{
+		"app.yaml":    "name: app\nlabels: !include labels.yaml\n",
+		"labels.yaml": "team: platform\n",
+	})
+
+	leaves, err := loader(read, variables.MergeAppend).Origins([]settings.File{{Path: "app.yaml"}})
+
+	require.NoError(t, err)
+	assert.Equal(t, []settings.Leaf{
+		{Path: []string{"name"}, File: "app.yaml", Line: 1},
+		{Path: []string{"labels", "team"}, File: "labels.yaml", Line: 1},
+	}, leaves)
+}
@@ -0,0 +1,34 @@
+package settings
+
+import "path/filepath"
+
+// LibraryKey is the settings key naming template libraries: a directory or
+// glob of partials, or a list of them. A relative path is resolved against the
+// directory of the file naming it, as an !include is, so a settings file
+// discovered up the tree still finds the library beside it.
+const LibraryKey = "lib"
+
+// resolveLibrary resolves the relative paths under LibraryKey in values, read
+// from the settings file at path, against that file's directory. A value that
+// is not a path is left for the caller to reject.
+func resolveLibrary(path string, values map[string]any) {
+	switch library := values[LibraryKey].(type) {
+	case string:
+		values[LibraryKey] = RelativeTo(path, library)
+	case []any:
+		for i, entry := range library {
+			if entry, isPath := entry.(string); isPath {
+				library[i] = RelativeTo(path, entry)
+			}
+		}
+	}
+}
+
+// RelativeTo returns target resolved against the directory of the file at
+// from, or target itself when it is absolute.
+func RelativeTo(from, target string) string {
+	if filepath.IsAbs(target) {
+		return target
+	}
+	return filepath.Join(filepath.Dir(from), target)
+}
@@ -5,10 +5,13 @@
 package settings
 
 import (
-	"dario.cat/mergo"
+	"slices"
+	"strings"
+
 	"gopkg.in/yaml.v3"
 
 	"github.com/gomatic/renderizer/internal/constants"
+	"github.com/gomatic/renderizer/internal/environment"
 	"github.com/gomatic/renderizer/internal/variables"
 )
 
@@ -18,58 +21,222 @@ type ReadFile func(name string) ([]byte, error)
 
 // File is a settings file to load. An optional file that does not exist is
 // skipped rather than failing — this is how the implicit default settings file
-// stays optional.
+// stays optional. A non-empty Strategy merges this file under it instead of
+// the strategy given to Load, which is how an overlay overrides its base.
 type File struct {
 	Path       string
+	Strategy   variables.MergeStrategy
 	IsOptional bool
 }
 
+// Loader reads settings files through its injected reader, interpolating
+// `${VAR}` references from Environment, typing values with Format (guessing at
+// the type of string values only when Inference is set), and merging
+// files under Strategy. When Templating is enabled each file is rendered as a
+// template before it is parsed. It carries no state between loads.
+type Loader struct {
+	Read        ReadFile
+	Environment environment.Variables
+	Templating  Templating
+	Format      variables.TimeFormat
+	Strategy    variables.MergeStrategy
+	Inference   variables.Inference
+}
+
 // Load reads each file in order, parses and retypes its YAML, and merges the
-// results into one context. Later files append to slices from earlier ones.
-func Load(read ReadFile, files []File, format variables.TimeFormat) (variables.Context, error) {
+// results into one context under the loader's strategy — by default, later
+// files append to slices from earlier ones. A key tagged with a strategy name
+// (e.g. `items: !replace [...]`) merges under that strategy instead, along
+// with everything beneath it. A value tagged `!include path.yaml` is replaced
+// by that file, resolved relative to the including one.
+func (l Loader) Load(files []File) (variables.Context, error) {
 	merged := map[string]any{}
 	for _, file := range files {
-		loaded, err := loadFile(read, file, format)
+		loaded, err := l.loadFile(file)
 		if err != nil {
 			return nil, err
 		}
-		if err := mergeInto(merged, loaded); err != nil {
+		if err := variables.Merge(merged, loaded.values, fileStrategy(file, l.Strategy), loaded.directives); err != nil {
 			return nil, err
 		}
 	}
 	return merged, nil
 }
 
-// loadFile reads and parses one settings file, returning a nil map when an
-// optional file is absent.
-func loadFile(read ReadFile, file File, format variables.TimeFormat) (map[string]any, error) {
-	data, err := read(file.Path)
+// fileStrategy returns the strategy file merges under: its own, or fallback.
+func fileStrategy(file File, fallback variables.MergeStrategy) variables.MergeStrategy {
+	if file.Strategy != "" {
+		return file.Strategy
+	}
+	return fallback
+}
+
+// parsed is one settings file's retyped values and the per-key merge
+// directives its tags declared.
+type parsed struct {
+	values     map[string]any
+	directives variables.Directives
+}
+
+// loadFile reads and parses one settings file, returning no values when an
+// optional file is absent. The library paths it names are resolved against
+// its directory.
+func (l Loader) loadFile(file File) (parsed, error) {
+	doc, isPresent, err := l.readDocument(file)
+	if err != nil || !isPresent {
+		return parsed{}, err
+	}
+	loaded, err := l.parse(file.Path, doc)
+	if err != nil {
+		return parsed{}, err
+	}
+	resolveLibrary(file.Path, loaded.values)
+	return loaded, nil
+}
+
+// readDocument reads and expands one settings file, reporting an absent
+// optional file as not present rather than failing.
+func (l Loader) readDocument(file File) (document, bool, error) {
+	data, err := l.Read(file.Path)
 	if err != nil {
 		if file.IsOptional {
-			return nil, nil
+			return document{}, false, nil
 		}
-		return nil, constants.ErrReadSettings.With(err, file.Path)
+		return document{}, false, constants.ErrReadSettings.With(err, file.Path)
+	}
+	if l.Templating.IsEnabled {
+		doc, err := l.settle(file.Path, data)
+		return doc, err == nil, err
 	}
-	return parse(data, format)
+	doc, err := l.expander(nil).parseDocument(file.Path, data)
+	return doc, err == nil, err
+}
+
+// expander returns the expander for this loader's files, rendering each
+// through render when it is non-nil.
+func (l Loader) expander(render func(string, []byte) ([]byte, error)) expander {
+	return expander{read: l.Read, render: render, env: l.Environment}
 }
 
-// parse unmarshals YAML into a map and retypes its leaves.
-func parse(data []byte, format variables.TimeFormat) (map[string]any, error) {
+// parse decodes an expanded document into a map, collecting and removing
+// merge-strategy tags so they decode as plain values, and retypes its leaves.
+func (l Loader) parse(path string, doc document) (parsed, error) {
+	directives := variables.Directives{}
+	collectDirectives(&doc.root, nil, directives)
 	loaded := map[string]any{}
-	if err := yaml.Unmarshal(data, &loaded); err != nil {
-		return nil, constants.ErrParseSettings.With(err)
+	if err := doc.root.Decode(&loaded); err != nil {
+		return parsed{}, constants.ErrParseSettings.With(err, path)
+	}
+	return parsed{values: variables.Retype(loaded, l.Format, false, l.Inference), directives: directives}, nil
+}
+
+// collectDirectives records every mapping value tagged with a merge strategy
+// under its key path, then clears the tag so the value decodes by its content.
+func collectDirectives(node *yaml.Node, path []string, directives variables.Directives) {
+	if strategy, ok := directive(node); ok {
+		directives.Set(path, strategy)
+		node.Tag = ""
+	}
+	if node.Kind != yaml.MappingNode {
+		for _, child := range node.Content {
+			collectDirectives(child, path, directives)
+		}
+		return
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		collectDirectives(node.Content[i+1], append(slices.Clone(path), node.Content[i].Value), directives)
+	}
+}
+
+// directive returns the merge strategy a node's tag names, if it names one.
+func directive(node *yaml.Node) (variables.MergeStrategy, bool) {
+	name, isLocal := strings.CutPrefix(node.Tag, "!")
+	if !isLocal || !variables.IsKnownMergeStrategy(name) {
+		return "", false
 	}
-	return variables.Retype(loaded, format, false), nil
+	return variables.MergeStrategy(name), true
+}
+
+// Leaf is one value a settings file defines: its key path, the file and line
+// defining it, whether it is a list, and the merge strategy a tag on it or an
+// enclosing key — or else its File — declared (empty when none did).
+type Leaf struct {
+	Strategy variables.MergeStrategy
+	File     string
+	Path     []string
+	Line     int
+	IsList   bool
 }
 
-// mergeInto merges src into dst, appending slices, leaving dst unchanged when
-// src is nil.
-func mergeInto(dst, src map[string]any) error {
-	if src == nil {
-		return nil
+// Origins lists every leaf the files define, in file order, so a caller can
+// attribute each merged value to the line that supplied it — a leaf from an
+// included file names that file. It reads the files with the same rules as
+// Load: an absent optional file contributes nothing.
+func (l Loader) Origins(files []File) ([]Leaf, error) {
+	var leaves []Leaf
+	for _, file := range files {
+		doc, isPresent, err := l.readDocument(file)
+		if err != nil {
+			return nil, err
+		}
+		if isPresent {
+			leaves = appendLeaves(leaves, doc, Leaf{File: file.Path, Strategy: file.Strategy}, &doc.root)
+		}
 	}
-	if err := mergo.Merge(&dst, src, mergo.WithAppendSlice); err != nil {
-		return constants.ErrMergeContext.With(err)
+	return leaves, nil
+}
+
+// appendLeaves walks node, appending a Leaf for every scalar or list under a
+// mapping key, attributed to the line of that key. at carries the position
+// reached so far. Documents and aliases are looked through to what they hold.
+func appendLeaves(leaves []Leaf, doc document, at Leaf, node *yaml.Node) []Leaf {
+	at = at.within(doc, node)
+	switch node.Kind {
+	case yaml.DocumentNode:
+		for _, child := range node.Content {
+			leaves = appendLeaves(leaves, doc, at, child)
+		}
+		return leaves
+	case yaml.AliasNode:
+		return appendLeaves(leaves, doc, at, node.Alias)
+	case yaml.MappingNode:
+		if len(node.Content) > 0 {
+			return appendMapping(leaves, doc, at, node)
+		}
+	case yaml.ScalarNode, yaml.SequenceNode:
+	}
+	return appendLeaf(leaves, at, node)
+}
+
+// appendLeaf appends at as the leaf node is, unless it is the document root.
+func appendLeaf(leaves []Leaf, at Leaf, node *yaml.Node) []Leaf {
+	if len(at.Path) == 0 {
+		return leaves
+	}
+	at.IsList = node.Kind == yaml.SequenceNode
+	return append(leaves, at)
+}
+
+// within returns at as it applies inside node: under the strategy node's tag
+// declares, and attributed to the file node was included from.
+func (at Leaf) within(doc document, node *yaml.Node) Leaf {
+	if strategy, ok := directive(node); ok {
+		at.Strategy = strategy
+	}
+	if file, ok := doc.files[node]; ok {
+		at.File = file
+	}
+	return at
+}
+
+// appendMapping appends the leaves under each key of a mapping node.
+func appendMapping(leaves []Leaf, doc document, at Leaf, node *yaml.Node) []Leaf {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key := node.Content[i]
+		child := at
+		child.Path = append(slices.Clone(at.Path), key.Value)
+		child.Line = key.Line
+		leaves = appendLeaves(leaves, doc, child, node.Content[i+1])
 	}
-	return nil
+	return leaves
 }
@@ -26,6 +26,11 @@ func reader(files map[string]string) settings.ReadFile {
 	}
 }
 
+// loader builds a Loader reading through read and merging under strategy.
+func loader(read settings.ReadFile, strategy variables.MergeStrategy) settings.Loader {
+	return settings.Loader{Read: read, Format: timeFormat, Strategy: strategy, Inference: true}
+}
+
 func TestLoad(t *testing.T) {
 	t.Parallel()
 	read := reader(map[string]string{
@@ -70,7 +75,7 @@ func TestLoad(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
-			got, err := settings.Load(read, tt.files, timeFormat)
+			got, err := loader(read, variables.MergeAppend).Load(tt.files)
 			if tt.wantErr != nil {
 				require.ErrorIs(t, err, tt.wantErr)
 				return
@@ -87,6 +92,104 @@ func TestLoadMergeConflict(t *testing.T) {
 		"slice.yaml": "a:\n  - 1\n",
 		"map.yaml":   "a:\n  b: 2\n",
 	})
-	_, err := settings.Load(read, []settings.File{{Path: "map.yaml"}, {Path: "slice.yaml"}}, timeFormat)
+	files := []settings.File{{Path: "map.yaml"}, {Path: "slice.yaml"}}
+	_, err := loader(read, variables.MergeAppend).Load(files)
 	require.ErrorIs(t, err, constants.ErrMergeContext)
 }
+
+// TestLoadStrategyDecidesWhetherALaterListReplacesAnEarlierOne names the point
+// of --merge: under the default a later file can only add to a list, so an
+// overlay that means "use exactly these" silently keeps the base entries too.
+// A strategy tag on one key must change that key alone.
+func TestLoadStrategyDecidesWhetherALaterListReplacesAnEarlierOne(t *testing.T) {
+	t.Parallel()
+	read := reader(map[string]string{
+		"base.yaml":    "name: base\nitems: [a, b]\ntags: [x]\n",
+		"overlay.yaml": "name: overlay\nitems: [b, c]\ntags: [y]\n",
+		"tagged.yaml":  "items: !replace [c]\ntags: [y]\n",
+	})
+	load := func(strategy variables.MergeStrategy, overlay string) variables.Context {
+		t.Helper()
+		files := []settings.File{{Path: "base.yaml"}, {Path: overlay}}
+		got, err := loader(read, strategy).Load(files)
+		require.NoError(t, err)
+		return got
+	}
+
+	assert.Equal(t,
+		variables.Context{"name": "base", "items": []any{"a", "b", "b", "c"}, "tags": []any{"x", "y"}},
+		load(variables.MergeAppend, "overlay.yaml"))
+	assert.Equal(t,
+		variables.Context{"name": "base", "items": []any{"a", "b", "c"}, "tags": []any{"x", "y"}},
+		load(variables.MergeUniqueAppend, "overlay.yaml"))
+	assert.Equal(t,
+		variables.Context{"name": "overlay", "items": []any{"b", "c"}, "tags": []any{"y"}},
+		load(variables.MergeReplace, "overlay.yaml"))
+	assert.Equal(t,
+		variables.Context{"name": "base", "items": []any{"c"}, "tags": []any{"x", "y"}},
+		load(variables.MergeAppend, "tagged.yaml"),
+		"the tag replaces its own key and leaves the others appending")
+}
+
+func TestLoadFileStrategyOverridesTheDefault(t *testing.T) {
+	t.Parallel()
+	read := reader(map[string]string{
+		"base.yaml":    "name: base\nitems: [a]\n",
+		"overlay.yaml": "name: overlay\nitems: [b]\n",
+	})
+	files := []settings.File{{Path: "base.yaml"}, {Path: "overlay.yaml", Strategy: variables.MergeReplace}}
+
+	got, err := loader(read, variables.MergeAppend).Load(files)
+
+	require.NoError(t, err)
+	assert.Equal(t, variables.Context{"name": "overlay", "items": []any{"b"}}, got)
+}
+
+func TestOrigins(t *testing.T) {
+	t.Parallel()
+	read := reader(map[string]string{
+		"a.yaml": "name: First\nnested:\n  deep: x\nitems:\n  - one\n",
+		"b.yaml": "name: Second\nnested: !replace\n  deep: y\n",
+	})
+	leaves, err := loader(read, variables.MergeAppend).Origins([]settings.File{
+		{Path: "a.yaml"}, {Path: "missing.yaml", IsOptional: true}, {Path: "b.yaml"},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []settings.Leaf{
+		{Path: []string{"name"}, File: "a.yaml", Line: 1},
+		{Path: []string{"nested", "deep"}, File: "a.yaml", Line: 3},
+		{Path: []string{"items"}, File: "a.yaml", Line: 4, IsList: true},
+		{Path: []string{"name"}, File: "b.yaml", Line: 1},
+		{Path: []string{"nested", "deep"}, File: "b.yaml", Line: 3, Strategy: variables.MergeReplace},
+	}, leaves)
+}
+
+func TestOriginsErrors(t *testing.T) {
+	t.Parallel()
+	read := reader(map[string]string{"bad.yaml": "::: not yaml :::"})
+
+	_, err := loader(read, variables.MergeAppend).Origins([]settings.File{{Path: "missing.yaml"}})
+	require.ErrorIs(t, err, constants.ErrReadSettings)
+
+	_, err = loader(read, variables.MergeAppend).Origins([]settings.File{{Path: "bad.yaml"}})
+	require.ErrorIs(t, err, constants.ErrParseSettings)
+}
+
+// TestLoadResolvesLibraryPathsAgainstTheirFile pins that the library paths a
+// settings file names are found beside it, wherever renderizer runs, and that
+// the lists of several files add up.
+func TestLoadResolvesLibraryPathsAgainstTheirFile(t *testing.T) {
+	t.Parallel()
+	read := reader(map[string]string{
+		"repo/.renderizer.yaml": "lib: partials\n",
+		"repo/app/app.yaml":     "lib:\n  - lib/*.tmpl\n  - /abs/lib\n",
+	})
+
+	got, err := loader(read, variables.MergeAppend).Load([]settings.File{{Path: "repo/.renderizer.yaml"}})
+	require.NoError(t, err)
+	assert.Equal(t, "repo/partials", got[settings.LibraryKey])
+
+	got, err = loader(read, variables.MergeAppend).Load([]settings.File{{Path: "repo/app/app.yaml"}})
+	require.NoError(t, err)
+	assert.Equal(t, []any{"repo/app/lib/*.tmpl", "/abs/lib"}, got[settings.LibraryKey])
+}
@@ -0,0 +1,121 @@
+package settings
+
+import (
+	"bytes"
+	"errors"
+	"slices"
+
+	"github.com/gomatic/renderizer/internal/constants"
+	"github.com/gomatic/renderizer/internal/template"
+	"github.com/gomatic/renderizer/internal/variables"
+)
+
+// Templated settings: a file rendered through template.Render before it is
+// parsed, so a value can be derived from the command line, the environment,
+// or other values in the same file. A value may refer to one defined after
+// it, so the file is rendered repeatedly — each pass against the values the
+// previous pass produced — until its text stops changing.
+
+// maxTemplatingPasses bounds the renders spent settling a templated file. A
+// chain of references settles in about one pass per link; a file still
+// changing after this many refers to itself without end.
+const maxTemplatingPasses = 8
+
+// Templating renders settings files as templates against Data — typically
+// the command-line assignments and the environment binding — which wins over
+// the file's own values of the same name. Funcs, Missing, and Delims are the
+// template functions, missingkey option, and action delimiters, as for the
+// main template.
+type Templating struct {
+	Funcs     map[string]any
+	Data      map[string]any
+	Missing   template.MissingKey
+	Delims    template.Delims
+	IsEnabled bool
+}
+
+// settle renders the file at path until a fixpoint and returns the settled
+// document. The passes tolerate missing keys, since a key may be defined by a
+// later pass; the settled text is rendered once more with the configured
+// missingkey option, so a key nothing defines still fails as configured.
+func (l Loader) settle(path string, source []byte) (document, error) {
+	values := l.seed(path, source)
+	var previous [][]byte
+	for range maxTemplatingPasses {
+		doc, rendered, err := l.renderDocument(path, source, values, template.MissingKeyZero)
+		if err != nil {
+			return document{}, err
+		}
+		if slices.EqualFunc(rendered, previous, bytes.Equal) {
+			doc, _, err := l.renderDocument(path, source, values, l.Templating.Missing)
+			return doc, err
+		}
+		next, err := l.parse(path, doc)
+		if err != nil {
+			return document{}, err
+		}
+		values, previous = next.values, rendered
+	}
+	return document{}, constants.ErrParseSettings.With(nil, path, "did not settle after", maxTemplatingPasses, "template passes")
+}
+
+// seed returns the values of the file as written, before any rendering, so
+// the first pass can already see the file's structure — `{{ .App.Name }}`
+// resolves through a map defined later in the file. Text that is not yet
+// valid YAML seeds nothing.
+func (l Loader) seed(path string, source []byte) map[string]any {
+	doc, err := l.expander(nil).parseDocument(path, source)
+	if err != nil {
+		return nil
+	}
+	seeded, err := l.parse(path, doc)
+	if err != nil {
+		return nil
+	}
+	return seeded.values
+}
+
+// renderDocument renders the file and every file it includes against values
+// beneath the templating data, returning the parsed document and each
+// rendered text in the order rendered.
+func (l Loader) renderDocument(
+	path string,
+	source []byte,
+	values map[string]any,
+	missing template.MissingKey,
+) (document, [][]byte, error) {
+	data := map[string]any{}
+	for _, layer := range []map[string]any{values, l.Templating.Data} {
+		if err := variables.Merge(data, clone(layer), variables.MergeReplace, nil); err != nil {
+			return document{}, nil, constants.ErrParseSettings.With(err, path)
+		}
+	}
+	var rendered [][]byte
+	render := func(name string, text []byte) ([]byte, error) {
+		out, err := template.Render(l.Templating.Funcs, missing, template.Syntax{Delims: l.Templating.Delims},
+			template.Name(name), text, data)
+		if err != nil {
+			// Detached from the template sentinels: this is a settings
+			// failure, not a failure of the template being rendered.
+			return nil, constants.ErrParseSettings.With(errors.New(err.Error()), name)
+		}
+		rendered = append(rendered, out)
+		return out, nil
+	}
+	doc, err := l.expander(render).parseDocument(path, source)
+	return doc, rendered, err
+}
+
+// clone copies the maps nested in values, so merging into the copy leaves
+// values untouched. Lists and scalars are shared; merging under replace never
+// mutates them in place.
+func clone(values map[string]any) map[string]any {
+	copied := make(map[string]any, len(values))
+	for key, value := range values {
+		if nested, ok := value.(map[string]any); ok {
+			value = clone(nested)
+		}
+		copied[key] = value
+	}
+	return copied
+}
@@ -0,0 +1,102 @@
+package settings_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gomatic/renderizer/internal/constants"
+	"github.com/gomatic/renderizer/internal/settings"
+	"github.com/gomatic/renderizer/internal/template"
+	"github.com/gomatic/renderizer/internal/variables"
+)
+
+func templated(files map[string]string, data map[string]any) settings.Loader {
+	load := loader(reader(files), variables.MergeAppend)
+	load.Templating = settings.Templating{
+		Funcs:     template.Funcs(false),
+		Data:      data,
+		Missing:   "error",
+		IsEnabled: true,
+	}
+	return load
+}
+
+// TestLoadTemplatedSettingsSettle pins the fixpoint: a value may refer to the
+// command line, to an earlier value, or to one defined after it — through a
+// chain of references — and an included file renders against the same data.
+func TestLoadTemplatedSettingsSettle(t *testing.T) {
+	t.Parallel()
+	load := templated(map[string]string{
+		"s.yaml": `image: "{{ .Registry }}/{{ .App.Name }}:{{ .Tag }}"` + "\n" +
+			`tag: "{{ .Version }}"` + "\n" +
+			"App:\n  Name: '{{ .Prefix }}-api'\n" +
+			"Version: v1\n" +
+			"meta: !include meta.yaml\n",
+		"meta.yaml": `owner: "{{ .User }}"` + "\n",
+	}, map[string]any{"Registry": "ghcr.io", "Prefix": "shop", "User": "alice", "Tag": "cli"})
+
+	got, err := load.Load([]settings.File{{Path: "s.yaml"}})
+
+	require.NoError(t, err)
+	assert.Equal(t, variables.Context{
+		"image":   "ghcr.io/shop-api:cli",
+		"tag":     "v1",
+		"App":     map[string]any{"Name": "shop-api"},
+		"Version": "v1",
+		"meta":    map[string]any{"owner": "alice"},
+	}, got)
+}
+
+// TestLoadTemplatedSettingsStructure pins that a template may generate YAML
+// structure, not just scalar text.
+func TestLoadTemplatedSettingsStructure(t *testing.T) {
+	t.Parallel()
+	load := templated(map[string]string{
+		"s.yaml": "ports:\n{{- range .Ports }}\n  - {{ . }}\n{{- end }}\n",
+	}, map[string]any{"Ports": []any{80, 443}})
+
+	got, err := load.Load([]settings.File{{Path: "s.yaml"}})
+
+	require.NoError(t, err)
+	assert.Equal(t, variables.Context{"ports": []any{int64(80), int64(443)}}, got)
+}
+
+// TestLoadTemplatedSettingsErrors pins that every templating failure is a
+// settings parse error naming the file — never a template error, which would
+// blame the main template and exit with its status.
+func TestLoadTemplatedSettingsErrors(t *testing.T) {
+	t.Parallel()
+	for _, tc := range []struct {
+		name  string
+		files map[string]string
+		want  string
+	}{
+		{name: "parse", files: map[string]string{"s.yaml": "a: '{{ .A '"}, want: "s.yaml"},
+		{name: "missing key", files: map[string]string{"s.yaml": "a: '{{ .Nowhere }}'"}, want: "s.yaml"},
+		{name: "included", files: map[string]string{
+			"s.yaml":   "a: !include inc.yaml",
+			"inc.yaml": "b: '{{ fail \"boom\" }}'",
+		}, want: "inc.yaml"},
+		{name: "no fixpoint", files: map[string]string{"s.yaml": "n: '{{ .n }}x'"}, want: "did not settle after 8 template passes"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := templated(tc.files, nil).Load([]settings.File{{Path: "s.yaml"}})
+			require.ErrorIs(t, err, constants.ErrParseSettings)
+			require.NotErrorIs(t, err, constants.ErrParseTemplate)
+			require.NotErrorIs(t, err, constants.ErrExecuteTemplate)
+			assert.Contains(t, err.Error(), tc.want)
+		})
+	}
+}
+
+func TestLoadUntemplatedSettingsKeepTemplateText(t *testing.T) {
+	t.Parallel()
+	got, err := loader(reader(map[string]string{"s.yaml": "a: '{{ .A }}'"}), variables.MergeAppend).
+		Load([]settings.File{{Path: "s.yaml"}})
+
+	require.NoError(t, err)
+	assert.Equal(t, variables.Context{"a": "{{ .A }}"}, got)
+}
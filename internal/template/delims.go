@@ -0,0 +1,34 @@
+package template
+
+import (
+	"strings"
+
+	"github.com/gomatic/renderizer/internal/constants"
+)
+
+// Delimiters: a template whose output is itself a template for another tool —
+// a Helm chart, a GitHub Actions workflow — is full of {{ }} that belong to
+// that tool, so renderizer's own actions need a pair of delimiters of their
+// own.
+
+// Delims are the action delimiters of a template. The zero value is the
+// text/template default pair, {{ and }}.
+type Delims struct {
+	Left  string
+	Right string
+}
+
+// ParseDelims parses spec, a comma-separated pair such as "[[,]]", into
+// Delims. An empty spec is the default pair; one lacking either half fails
+// with ErrParseDelims.
+func ParseDelims(spec string) (Delims, error) {
+	if spec == "" {
+		return Delims{}, nil
+	}
+	left, right, isPair := strings.Cut(spec, ",")
+	left, right = strings.TrimSpace(left), strings.TrimSpace(right)
+	if !isPair || left == "" || right == "" {
+		return Delims{}, constants.ErrParseDelims.With(nil, spec)
+	}
+	return Delims{Left: left, Right: right}, nil
+}
@@ -0,0 +1,163 @@
+package template
+
+import (
+	"fmt"
+	"maps"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+	"text/template"
+
+	"github.com/gomatic/renderizer/internal/constants"
+)
+
+// Suggestions for a located failure: when a template refers to a key its data
+// lacks or a function its set does not define, the name it most likely meant
+// is the closest, by edit distance, of the names that do exist there.
+
+var (
+	// unknownFunction matches the reason of a parse error naming a function
+	// the set does not define.
+	unknownFunction = regexp.MustCompile(`^function "(.+)" not defined`)
+	// missingEntry matches the reason of an execute error naming a key the
+	// data lacks.
+	missingEntry = regexp.MustCompile(`map has no entry for key "(.+)"`)
+)
+
+// Diagnose completes located with a suggestion drawn from funcs or data, and
+// places an unknown function, which text/template reports without a column,
+// at its first occurrence in the source line. It returns located as an error.
+func Diagnose(located *constants.TemplateError, funcs template.FuncMap, data any) error {
+	if match := unknownFunction.FindStringSubmatch(located.Reason); match != nil {
+		diagnoseFunction(located, match[1], funcs)
+		return located
+	}
+	if match := missingEntry.FindStringSubmatch(located.Reason); match != nil {
+		if path, isField := parent(located.Path, match[1]); isField {
+			located.Suggestion = closest(match[1], keysAt(data, path))
+		}
+	}
+	return located
+}
+
+// diagnoseFunction completes the failure of a template calling the function
+// name funcs lacks. A standard function a policy removed is reported as such,
+// wrapped in ErrRemovedFunction, rather than as a typo to correct.
+func diagnoseFunction(located *constants.TemplateError, name string, funcs template.FuncMap) {
+	if at := strings.Index(located.Source, name); located.Column == 0 && at >= 0 {
+		located.Column = at + 1
+	}
+	if _, isStandard := Funcs(false)[name]; isStandard {
+		located.Cause = constants.ErrRemovedFunction.With(located.Cause, name)
+		located.Reason = fmt.Sprintf("function %q is not in the allowed function set", name)
+		return
+	}
+	located.Suggestion = closest(name, slices.Collect(maps.Keys(funcs)))
+}
+
+// parent returns the keys of the field chain path leading to key: the map the
+// lookup of key failed in. A path that is not a field chain from the data —
+// a pipeline, or a variable other than $ — has none.
+func parent(path, key string) ([]string, bool) {
+	chain, isField := strings.CutPrefix(strings.TrimPrefix(path, "$"), ".")
+	if !isField || strings.ContainsAny(chain, " ()|$") {
+		return nil, false
+	}
+	steps := strings.Split(chain, ".")
+	at := slices.Index(steps, key)
+	if at < 0 {
+		return nil, false
+	}
+	return steps[:at], true
+}
+
+// keysAt returns the keys of the string-keyed map reached from data through
+// path, or nil when path does not reach one.
+func keysAt(data any, path []string) []string {
+	value := reflect.ValueOf(data)
+	for _, key := range path {
+		value = entry(value, key)
+	}
+	value = indirect(value)
+	if !isStringMap(value) {
+		return nil
+	}
+	keys := make([]string, 0, value.Len())
+	for _, key := range value.MapKeys() {
+		keys = append(keys, key.String())
+	}
+	return keys
+}
+
+// entry returns the value at key in the string-keyed map value, or the zero
+// Value when value is not one or has no such key.
+func entry(value reflect.Value, key string) reflect.Value {
+	value = indirect(value)
+	if !isStringMap(value) {
+		return reflect.Value{}
+	}
+	return value.MapIndex(reflect.ValueOf(key).Convert(value.Type().Key()))
+}
+
+// indirect returns the value behind any interfaces and pointers wrapping value.
+func indirect(value reflect.Value) reflect.Value {
+	for value.Kind() == reflect.Interface || value.Kind() == reflect.Pointer {
+		value = value.Elem()
+	}
+	return value
+}
+
+// isStringMap reports whether value is a map keyed by strings.
+func isStringMap(value reflect.Value) bool {
+	return value.Kind() == reflect.Map && value.Type().Key().Kind() == reflect.String
+}
+
+// closest returns the candidate nearest target, ignoring case, within a third
+// of its length in edits, or one edit for a short target; ties go to the first
+// in sorted order. It returns empty when none is that near.
+func closest(target string, candidates []string) string {
+	slices.Sort(candidates)
+	best, within := "", max(1, len(target)/3)+1
+	for _, candidate := range candidates {
+		if edits := distance(strings.ToLower(target), strings.ToLower(candidate)); edits < within {
+			best, within = candidate, edits
+		}
+	}
+	return best
+}
+
+// distance returns the optimal string alignment distance between a and b: the
+// insertions, deletions, substitutions, and adjacent transpositions that turn
+// one into the other, so a swapped pair of letters costs one edit.
+func distance(a, b string) int {
+	from, to := []rune(a), []rune(b)
+	rows := make([][]int, len(from)+1)
+	for i := range rows {
+		rows[i] = make([]int, len(to)+1)
+		rows[i][0] = i
+	}
+	for j := range rows[0] {
+		rows[0][j] = j
+	}
+	for i := 1; i <= len(from); i++ {
+		for j := 1; j <= len(to); j++ {
+			rows[i][j] = edit(rows, from, to, i, j)
+		}
+	}
+	return rows[len(from)][len(to)]
+}
+
+// edit returns the distance between the first i runes of from and the first j
+// of to, given the distances between their shorter prefixes.
+func edit(rows [][]int, from, to []rune, i, j int) int {
+	substitution := 1
+	if from[i-1] == to[j-1] {
+		substitution = 0
+	}
+	best := min(rows[i-1][j]+1, rows[i][j-1]+1, rows[i-1][j-1]+substitution)
+	if i > 1 && j > 1 && from[i-1] == to[j-2] && from[i-2] == to[j-1] {
+		best = min(best, rows[i-2][j-2]+1)
+	}
+	return best
+}
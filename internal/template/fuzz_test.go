@@ -34,7 +34,7 @@ func FuzzRenderFailsOnlyWithItsSentinels(f *testing.F) {
 	funcs := texttemplate.FuncMap{"boom": func() string { panic("boom") }}
 	data := map[string]any{"Name": "n", "Items": []int{1}, "A": true}
 	f.Fuzz(func(t *testing.T, source []byte) {
-		out, err := template.Render(funcs, template.MissingKey("zero"), "fuzz", source, data)
+		out, err := template.Render(funcs, template.MissingKey("zero"), template.Syntax{}, "fuzz", source, data)
 		if err != nil {
 			require.True(t,
 				errors.Is(err, constants.ErrParseTemplate) ||
@@ -44,7 +44,7 @@ func FuzzRenderFailsOnlyWithItsSentinels(f *testing.F) {
 			require.Nil(t, out, "a failed render must return no output")
 			return
 		}
-		again, err := template.Render(funcs, template.MissingKey("zero"), "fuzz", source, data)
+		again, err := template.Render(funcs, template.MissingKey("zero"), template.Syntax{}, "fuzz", source, data)
 		require.NoError(t, err, "a successful render must succeed again")
 		require.Equal(t, out, again, "rendering is deterministic for the same source and data")
 	})
@@ -0,0 +1,173 @@
+package template
+
+import (
+	"errors"
+	"io"
+	"maps"
+	"slices"
+	"text/template"
+	"text/template/parse"
+
+	"github.com/gomatic/renderizer/internal/constants"
+)
+
+// Helm's template functions, which Sprig leaves out: include renders a named
+// template into a string, so its output can be piped — to nindent, say — where
+// {{ template }} can only write it; tpl renders a string as a template; and
+// required fails the render when a value is missing. include and tpl need the
+// template set they run in, so Funcs holds stand-ins for them, and each
+// render binds its own in their place.
+//
+// Both render as text, whatever the template is parsed as: their result is a
+// string like any other, which a template parsed as HTML escapes where it is
+// printed.
+
+// maxNesting bounds how deeply include and tpl calls nest, so a template that
+// includes itself fails rather than exhausting the stack. It is well within
+// Helm's own bound of 1000.
+const maxNesting = 100
+
+// helmFuncs returns the Helm functions Funcs adds: required, and the
+// stand-ins for include and tpl.
+func helmFuncs() template.FuncMap {
+	return template.FuncMap{
+		"include":  unbound("include"),
+		"tpl":      unbound("tpl"),
+		"required": required,
+	}
+}
+
+// unbound returns the stand-in for the function name, which fails: it is only
+// callable once a render binds it.
+func unbound(name string) func(string, any) (string, error) {
+	return func(string, any) (string, error) {
+		return "", errors.New(name + " is only available while rendering")
+	}
+}
+
+// required returns value, failing with message when value is missing: nil, or
+// the empty string.
+func required(message string, value any) (any, error) {
+	if text, isString := value.(string); value == nil || isString && text == "" {
+		return nil, errors.New(message)
+	}
+	return value, nil
+}
+
+// helm binds include and tpl for one render of the template named name, parsed
+// from source with the given functions, missingkey option, and syntax.
+type helm struct {
+	funcs   template.FuncMap
+	set     *template.Template
+	limits  *limiter
+	source  []byte
+	syntax  Syntax
+	name    Name
+	missing MissingKey
+	depth   int
+}
+
+// bind returns the functions of the render limited by l: h's own, with those
+// l bounds and include and tpl bound in place of their stand-ins. A template
+// parsed as text includes from its own set; one parsed as HTML from a set
+// parsed from its source as text, when it first includes.
+func (h *helm) bind(l *limiter, parsed Parsed) template.FuncMap {
+	bound := maps.Clone(h.funcs)
+	maps.Copy(bound, l.funcs(h.funcs))
+	if _, isKept := h.funcs["include"]; isKept {
+		bound["include"] = h.include
+	}
+	if _, isKept := h.funcs["tpl"]; isKept {
+		bound["tpl"] = h.tpl
+	}
+	if text, isText := parsed.(textParsed); isText {
+		h.set = text.Template
+	}
+	h.funcs, h.limits = bound, l
+	return bound
+}
+
+// include renders the template named name against data.
+func (h *helm) include(name string, data any) (string, error) {
+	return h.nest(name, func(set *template.Template, w io.Writer) error {
+		return set.ExecuteTemplate(w, name, data)
+	})
+}
+
+// tpl renders text as a template against data. The text may invoke the
+// templates of the render's set.
+func (h *helm) tpl(text string, data any) (string, error) {
+	return h.nest(string(h.name)+":tpl", func(set *template.Template, w io.Writer) error {
+		parsed, err := h.parse(set, text)
+		if err != nil {
+			return err
+		}
+		return parsed.Execute(w, data)
+	})
+}
+
+// nest renders the template name into a string, within the render's limits,
+// with render, failing once include and tpl calls nest too deeply. That
+// failure is recorded as the render's, and each enclosing call returns it as
+// is, so runaway recursion reports one error rather than one per level.
+func (h *helm) nest(name string, render func(*template.Template, io.Writer) error) (string, error) {
+	if h.depth >= maxNesting {
+		return "", h.limits.fail(constants.ErrNestingLimit.With(nil, name, "nested more than", maxNesting, "deep"))
+	}
+	set, err := h.textSet()
+	if err != nil {
+		return "", err
+	}
+	h.depth++
+	defer func() { h.depth-- }()
+	out := &nested{limits: h.limits}
+	if err := render(set, out); err != nil {
+		if failed := h.limits.check(); failed != nil {
+			return "", failed
+		}
+		return "", err
+	}
+	return out.out.String(), nil
+}
+
+// parse parses text into set as the template tpl renders, instrumenting it
+// and any templates it defines.
+func (h *helm) parse(set *template.Template, text string) (*template.Template, error) {
+	before := textParsed{set}.Trees()
+	parsed, err := set.New(string(h.name) + ":tpl").Parse(text)
+	if err != nil {
+		return nil, err
+	}
+	h.limits.instrument(added(before, textParsed{parsed}.Trees())...)
+	return parsed, nil
+}
+
+// textSet returns the set include and tpl render from, parsing the render's
+// source as text the first time.
+func (h *helm) textSet() (*template.Template, error) {
+	if h.set != nil {
+		return h.set, nil
+	}
+	set := template.New(string(h.name)).
+		Delims(h.syntax.Delims.Left, h.syntax.Delims.Right).
+		Option("missingkey=" + string(h.missing)).
+		Funcs(h.funcs)
+	parsed, err := parseText(set, h.syntax.Library, h.source)
+	if err != nil {
+		return nil, err
+	}
+	h.limits.instrument(textParsed{parsed}.Trees()...)
+	h.set = parsed
+	return parsed, nil
+}
+
+// added returns the trees of after that are not among before.
+func added(before, after []*parse.Tree) []*parse.Tree {
+	var fresh []*parse.Tree
+	for _, tree := range after {
+		if !slices.Contains(before, tree) {
+			fresh = append(fresh, tree)
+		}
+	}
+	return fresh
+}
@@ -0,0 +1,68 @@
+package template
+
+import (
+	"text/template"
+	"text/template/parse"
+
+	"github.com/gomatic/renderizer/internal/constants"
+)
+
+// A library of partials: files of shared {{ define }} and {{ block }}
+// templates that every template of a run can invoke and override. The files
+// are parsed once, and each render adds copies of their trees to its own
+// template set before parsing its source, so a render's definitions override
+// the library's without touching it, and a render as HTML escapes its own
+// copies.
+
+// LibraryFile is one file of a library: its name and its source.
+type LibraryFile struct {
+	Name   Name
+	Source []byte
+}
+
+// Library is a parsed set of partials. The zero value is the empty library.
+type Library struct {
+	sources map[string][]byte
+	trees   []*parse.Tree
+}
+
+// ParseLibrary parses files, in order, into one library with the given
+// functions and action delimiters. A file defining a template an earlier file
+// defined overrides it. A failure wraps ErrParseTemplate in a
+// constants.TemplateError locating it in the failing file.
+func ParseLibrary(funcs template.FuncMap, delims Delims, files []LibraryFile) (Library, error) {
+	set := template.New("").Delims(delims.Left, delims.Right).Funcs(funcs)
+	library := Library{sources: map[string][]byte{}}
+	for _, file := range files {
+		if _, err := set.New(string(file.Name)).Parse(string(file.Source)); err != nil {
+			located := Locate(file.Name, file.Source, err, constants.ErrParseTemplate.With(err))
+			return Library{}, Diagnose(located, funcs, nil)
+		}
+		library.sources[string(file.Name)] = file.Source
+	}
+	for _, defined := range set.Templates() {
+		if defined.Tree != nil {
+			library.trees = append(library.trees, defined.Tree)
+		}
+	}
+	return library, nil
+}
+
+// copies returns a copy of each of the library's trees, for one render to
+// add to its template set.
+func (l Library) copies() []*parse.Tree {
+	copied := make([]*parse.Tree, len(l.trees))
+	for i, tree := range l.trees {
+		copied[i] = tree.Copy()
+	}
+	return copied
+}
+
+// locate fills in the source line of located when it failed in one of the
+// library's files rather than in the template rendered with it.
+func (l Library) locate(located *constants.TemplateError) *constants.TemplateError {
+	if source, isLibrary := l.sources[located.Template]; isLibrary && located.Source == "" {
+		located.Source = line(source, located.Line)
+	}
+	return located
+}
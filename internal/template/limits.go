@@ -0,0 +1,229 @@
+package template
+
+import (
+	"bytes"
+	"context"
+	"text/template"
+	"text/template/parse"
+	"time"
+
+	"github.com/gomatic/renderizer/internal/constants"
+)
+
+// Execution limits: a template is trusted to terminate, but a buggy one —
+// `{{ range until 100000000 }}` — pins a core and fills memory. A limiter
+// bounds one render: the output goes through it, failing past the byte cap,
+// and every range iteration calls it, failing past the iteration cap. Both
+// also fail once the render's context is done, which is how the timeout is
+// enforced: text/template cannot be interrupted, only made to fail at the next
+// write or iteration.
+
+type (
+	// Timeout bounds the wall-clock time of one render.
+	Timeout time.Duration
+	// OutputBytes bounds the size of one render's output.
+	OutputBytes int64
+	// Iterations bounds the range iterations of one render, across all of its
+	// range actions, counting the elements until and untilStep build as
+	// iterations. The strings seq and repeat build are not counted.
+	Iterations int64
+)
+
+// Limits bounds a render. A zero field sets no bound.
+type Limits struct {
+	Timeout       Timeout
+	MaxOutput     OutputBytes
+	MaxIterations Iterations
+}
+
+// tickName names the function each range iteration calls, and the variable
+// its result is assigned to. A template cannot refer to either itself: it is
+// parsed before they are added.
+const tickName = "renderizerTick"
+
+// limiter enforces Limits on one render, recording the first limit it failed
+// on so the failure is reported as that rather than as what text/template
+// made of it.
+type limiter struct {
+	ctx        context.Context
+	err        error
+	out        bytes.Buffer
+	limits     Limits
+	written    int64
+	iterations int64
+}
+
+// Write appends p to the output, failing once the output would exceed its cap
+// or the render's context is done.
+func (l *limiter) Write(p []byte) (int, error) {
+	if err := l.admit(l.written + int64(len(p))); err != nil {
+		return 0, err
+	}
+	l.written += int64(len(p))
+	return l.out.Write(p)
+}
+
+// admit fails unless output of size bytes fits within the cap and the
+// render's context is not done.
+func (l *limiter) admit(size int64) error {
+	if err := l.check(); err != nil {
+		return err
+	}
+	if l.limits.MaxOutput > 0 && size > int64(l.limits.MaxOutput) {
+		return l.fail(constants.ErrOutputLimit.With(nil, "more than", int64(l.limits.MaxOutput), "bytes"))
+	}
+	return nil
+}
+
+// tick counts one range iteration, failing once the iterations exceed their
+// cap or the render's context is done. It writes nothing.
+func (l *limiter) tick() (string, error) {
+	if err := l.reserve(1); err != nil {
+		return "", err
+	}
+	l.iterations++
+	return "", nil
+}
+
+// reserve fails unless count more iterations fit within the cap and the
+// render's context is not done.
+func (l *limiter) reserve(count uint64) error {
+	if err := l.check(); err != nil {
+		return err
+	}
+	if l.limits.MaxIterations > 0 && count > uint64(int64(l.limits.MaxIterations)-l.iterations) {
+		return l.fail(constants.ErrIterationLimit.With(nil, "more than", int64(l.limits.MaxIterations), "iterations"))
+	}
+	return nil
+}
+
+// check returns the recorded failure, recording the context's cause first if
+// it is done: the timeout, or whatever cancelled the render.
+func (l *limiter) check() error {
+	if l.err == nil && l.ctx.Err() != nil {
+		l.err = context.Cause(l.ctx)
+	}
+	return l.err
+}
+
+// fail records err as the render's failure and returns it.
+func (l *limiter) fail(err error) error {
+	l.err = err
+	return err
+}
+
+// funcs returns the functions the limiter adds to a parsed template: the
+// tick, and bounded replacements for the list builders among funcs, which
+// would otherwise allocate a list longer than could be ranged over before
+// the first iteration counted.
+func (l *limiter) funcs(funcs template.FuncMap) template.FuncMap {
+	bounded := template.FuncMap{tickName: l.tick}
+	if until, ok := funcs["until"].(func(int) []int); ok {
+		bounded["until"] = l.until(until)
+	}
+	if untilStep, ok := funcs["untilStep"].(func(int, int, int) []int); ok {
+		bounded["untilStep"] = l.untilStep(untilStep)
+	}
+	return bounded
+}
+
+// until returns until, failing rather than building a list of more elements
+// than the iterations left. A negative count counts down from zero, so it
+// yields as many elements as its absolute value.
+func (l *limiter) until(until func(int) []int) func(int) ([]int, error) {
+	return func(count int) ([]int, error) {
+		step := 1
+		if count < 0 {
+			step = -1
+		}
+		if err := l.reserve(steps(0, count, step)); err != nil {
+			return nil, err
+		}
+		return until(count), nil
+	}
+}
+
+// untilStep returns untilStep, failing rather than building a list of more
+// elements than the iterations left.
+func (l *limiter) untilStep(untilStep func(int, int, int) []int) func(int, int, int) ([]int, error) {
+	return func(start, stop, step int) ([]int, error) {
+		if err := l.reserve(steps(start, stop, step)); err != nil {
+			return nil, err
+		}
+		return untilStep(start, stop, step), nil
+	}
+}
+
+// steps returns how many elements untilStep(start, stop, step) yields: none
+// when step is zero or leads away from stop. It counts in uint64, so no span
+// between two ints overflows.
+func steps(start, stop, step int) uint64 {
+	switch {
+	case step > 0 && stop > start:
+		return (uint64(stop)-uint64(start)-1)/uint64(step) + 1
+	case step < 0 && stop < start:
+		return (uint64(start)-uint64(stop)-1)/-uint64(step) + 1
+	}
+	return 0
+}
+
+// instrument makes every range action in trees call the tick at the start of
+// each iteration.
+func (l *limiter) instrument(trees ...*parse.Tree) {
+	// The tick's own one-action tree; parsing it cannot fail. The action
+	// declares a variable rather than printing, so it writes nothing and
+	// html/template leaves it unescaped, whatever the context.
+	tick := template.Must(template.New(tickName).
+		Funcs(template.FuncMap{tickName: l.tick}).
+		Parse("{{ $" + tickName + " := " + tickName + " }}"))
+	for _, tree := range trees {
+		instrumentList(tree.Root, tick.Root.Nodes[0])
+	}
+}
+
+// nested collects output rendered into a string, an include's or a tpl's,
+// under the same cap and context as the render's own output: a string is
+// never longer than the output it could be written to.
+type nested struct {
+	limits *limiter
+	out    bytes.Buffer
+}
+
+// Write appends p to the string, failing as the render's output would.
+func (n *nested) Write(p []byte) (int, error) {
+	if err := n.limits.admit(int64(n.out.Len() + len(p))); err != nil {
+		return 0, err
+	}
+	return n.out.Write(p)
+}
+
+// instrumentList instruments the range actions within list.
+func instrumentList(list *parse.ListNode, tick parse.Node) {
+	if list == nil {
+		return
+	}
+	for _, node := range list.Nodes {
+		instrumentNode(node, tick)
+	}
+}
+
+// instrumentNode instruments node, when it is a range action, and the range
+// actions within its branches.
+func instrumentNode(node, tick parse.Node) {
+	switch n := node.(type) {
+	case *parse.RangeNode:
+		instrumentBranch(&n.BranchNode, tick)
+		n.List.Nodes = append([]parse.Node{tick}, n.List.Nodes...)
+	case *parse.IfNode:
+		instrumentBranch(&n.BranchNode, tick)
+	case *parse.WithNode:
+		instrumentBranch(&n.BranchNode, tick)
+	}
+}
+
+// instrumentBranch instruments the range actions within both of branch's
+// lists.
+func instrumentBranch(branch *parse.BranchNode, tick parse.Node) {
+	instrumentList(branch.List, tick)
+	instrumentList(branch.ElseList, tick)
+}
@@ -0,0 +1,50 @@
+package template
+
+import (
+	"bytes"
+	"regexp"
+	"strconv"
+
+	"github.com/gomatic/renderizer/internal/constants"
+)
+
+// position matches the location text/template prefixes its errors with:
+// "template: NAME:LINE: " for a parse error and "template: NAME:LINE:COL:
+// executing "NAME" at <NODE>: " for an execute error, or html/template with an
+// escape error: "html/template:NAME:LINE[:COL]: ". It captures the name, the
+// line, the zero-based column, and the node being evaluated.
+var position = regexp.MustCompile(`^(?:template: |html/template:)(.*?):(\d+)(?::(\d+))?: (?:executing ".*?" at <(.*?)>: )?`)
+
+// Locate wraps err, which wraps the text/template error cause, with the
+// position cause reports, the template source line at it, and the reason
+// following it. An error reporting no position is wrapped with the template
+// name alone. The column is one-based, and zero when cause reports none, as a
+// parse error never does.
+func Locate(name Name, source []byte, cause, err error) *constants.TemplateError {
+	message := cause.Error()
+	located := &constants.TemplateError{Cause: err, Template: string(name), Reason: message}
+	match := position.FindStringSubmatch(message)
+	if match == nil {
+		return located
+	}
+	located.Template, located.Path, located.Reason = match[1], match[4], message[len(match[0]):]
+	located.Line, _ = strconv.Atoi(match[2])
+	if match[3] != "" {
+		column, _ := strconv.Atoi(match[3])
+		located.Column = column + 1
+	}
+	if located.Template == string(name) {
+		located.Source = line(source, located.Line)
+	}
+	return located
+}
+
+// line returns the one-based line number of source, without its newline, or
+// empty when source has no such line.
+func line(source []byte, number int) string {
+	lines := bytes.Split(source, []byte("\n"))
+	if number < 1 || number > len(lines) {
+		return ""
+	}
+	return string(bytes.TrimSuffix(lines[number-1], []byte("\r")))
+}
@@ -0,0 +1,152 @@
+package template
+
+import (
+	"errors"
+	htmltemplate "html/template"
+	"io"
+	"text/template"
+	"text/template/parse"
+
+	"github.com/gomatic/renderizer/internal/constants"
+)
+
+// Parsing as text or as HTML: html/template parses exactly as text/template
+// does, then escapes each action for the HTML context it lands in, so data
+// holding markup cannot inject it. The escaping happens when the template is
+// first executed, and a template whose actions cannot be escaped — one in an
+// ambiguous context, say — fails then, before anything is written.
+
+type (
+	// HTMLEnabled parses a template with html/template rather than
+	// text/template.
+	HTMLEnabled bool
+)
+
+// Syntax is how a template's source is parsed: its action delimiters, whether
+// as HTML, and the library of partials parsed alongside it.
+type Syntax struct {
+	Library Library
+	Delims  Delims
+	IsHTML  HTMLEnabled
+}
+
+// Parsed is a template parsed as text or as HTML.
+type Parsed interface {
+	// Execute executes the template against data, writing the output to w.
+	Execute(w io.Writer, data any) error
+	// Body returns the tree of the template itself, or nil when its source
+	// only defines other templates.
+	Body() *parse.Tree
+	// Trees returns the trees of the template and those its source defines.
+	Trees() []*parse.Tree
+	// addFuncs adds funcs to the functions the template may call.
+	addFuncs(funcs template.FuncMap)
+}
+
+// Parse parses source as a template named name with the given functions and
+// missingkey option, in syntax. A failure wraps ErrParseTemplate in a
+// constants.TemplateError locating it in source and suggesting the function
+// meant.
+func Parse(funcs template.FuncMap, missing MissingKey, syntax Syntax, name Name, source []byte) (Parsed, error) {
+	parsed, err := parseSyntax(funcs, missing, syntax, name, source)
+	if err != nil {
+		located := Locate(name, source, err, constants.ErrParseTemplate.With(err))
+		return nil, Diagnose(located, funcs, nil)
+	}
+	return parsed, nil
+}
+
+// parseSyntax parses source with text/template or html/template, into a set
+// holding copies of the library's templates.
+func parseSyntax(funcs template.FuncMap, missing MissingKey, syntax Syntax, name Name, source []byte) (Parsed, error) {
+	option := "missingkey=" + string(missing)
+	if syntax.IsHTML {
+		set := htmltemplate.New(string(name)).
+			Delims(syntax.Delims.Left, syntax.Delims.Right).
+			Option(option).
+			Funcs(htmltemplate.FuncMap(funcs))
+		return parseHTML(set, syntax.Library, source)
+	}
+	set := template.New(string(name)).
+		Delims(syntax.Delims.Left, syntax.Delims.Right).
+		Option(option).
+		Funcs(funcs)
+	parsed, err := parseText(set, syntax.Library, source)
+	if err != nil {
+		return nil, err
+	}
+	return textParsed{parsed}, nil
+}
+
+// parseText adds the library's templates to set, then parses source into it.
+func parseText(set *template.Template, library Library, source []byte) (*template.Template, error) {
+	for _, tree := range library.copies() {
+		if _, err := set.AddParseTree(tree.Name, tree); err != nil {
+			return nil, err
+		}
+	}
+	return set.Parse(string(source))
+}
+
+// parseHTML adds the library's templates to set, then parses source into it.
+func parseHTML(set *htmltemplate.Template, library Library, source []byte) (Parsed, error) {
+	for _, tree := range library.copies() {
+		if _, err := set.AddParseTree(tree.Name, tree); err != nil {
+			return nil, err
+		}
+	}
+	parsed, err := set.Parse(string(source))
+	if err != nil {
+		return nil, err
+	}
+	return htmlParsed{parsed}, nil
+}
+
+// executeFailure wraps err, an error executing the template named name, in
+// ErrEscapeTemplate when html/template could not escape it and in
+// ErrExecuteTemplate otherwise, located in source.
+func executeFailure(name Name, source []byte, err error) *constants.TemplateError {
+	var escaping *htmltemplate.Error
+	if errors.As(err, &escaping) {
+		return Locate(name, source, err, constants.ErrEscapeTemplate.With(err))
+	}
+	return Locate(name, source, err, constants.ErrExecuteTemplate.With(err))
+}
+
+// textParsed is a template parsed by text/template.
+type textParsed struct{ *template.Template }
+
+// Body returns the tree of the template itself.
+func (p textParsed) Body() *parse.Tree { return p.Tree }
+
+// Trees returns the trees of the template and those it defines.
+func (p textParsed) Trees() []*parse.Tree {
+	var trees []*parse.Tree
+	for _, defined := range p.Templates() {
+		if defined.Tree != nil {
+			trees = append(trees, defined.Tree)
+		}
+	}
+	return trees
+}
+
+func (p textParsed) addFuncs(funcs template.FuncMap) { p.Funcs(funcs) }
+
+// htmlParsed is a template parsed by html/template.
+type htmlParsed struct{ *htmltemplate.Template }
+
+// Body returns the tree of the template itself.
+func (p htmlParsed) Body() *parse.Tree { return p.Tree }
+
+// Trees returns the trees of the template and those it defines.
+func (p htmlParsed) Trees() []*parse.Tree {
+	var trees []*parse.Tree
+	for _, defined := range p.Templates() {
+		if defined.Tree != nil {
+			trees = append(trees, defined.Tree)
+		}
+	}
+	return trees
+}
+
+func (p htmlParsed) addFuncs(funcs template.FuncMap) { p.Funcs(htmltemplate.FuncMap(funcs)) }
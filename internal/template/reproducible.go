@@ -0,0 +1,321 @@
+package template
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"maps"
+	"math/big"
+	"os"
+	"slices"
+	"text/template"
+	"time"
+
+	"github.com/gomatic/funcmap"
+
+	"github.com/gomatic/renderizer/internal/environment"
+)
+
+// Testing mode replaces every function whose result varies from run to run
+// with one drawing from a single seeded sequence and a fixed clock, so a run
+// renders the same bytes each time it is repeated with the same seed and
+// time. Its sequence functions count from one in each function set — a run
+// builds one, so a run's templates share one count, whatever ran in the
+// process before — and its environment functions read the environment it is
+// given rather than the process's. The functions that cannot be made
+// reproducible — those hashing or encrypting with a salt of their own, and
+// the certificate generators, which stamp the real time — fail in testing
+// mode rather than varying.
+
+// The characters of the random string functions' results.
+const (
+	alphaChars   = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	numericChars = "0123456789"
+	asciiChars   = " !\"#$%&'()*+,-./" + numericChars + ":;<=>?@ABCDEFGHIJKLMNOPQRSTUVWXYZ" +
+		"[\\]^_`abcdefghijklmnopqrstuvwxyz{|}~"
+)
+
+// The RSA keys genPrivateKey generates, as Sprig generates them.
+const (
+	rsaBits     = 4096
+	rsaExponent = 65537
+)
+
+// irreproducible are the functions testing mode cannot make reproducible.
+var irreproducible = []string{
+	"bcrypt", "encryptAES", "genCA", "genCAWithKey", "genSelfSignedCert",
+	"genSelfSignedCertWithKey", "genSignedCert", "genSignedCertWithKey", "htpasswd",
+}
+
+// Testing configures testing mode: whether it is on, the seed of the sequence
+// its random functions draw from, the time its clock is fixed at, or
+// clock.Format's time when zero, and the environment its environment
+// functions read.
+type Testing struct {
+	Now         time.Time
+	Environment environment.Variables
+	Seed        Seed
+	IsEnabled   TestingEnabled
+}
+
+// seeded draws the results of the reproducible functions from next, a
+// deterministicSequence, and the clock fixed at now.
+type seeded struct {
+	next func() int64
+	now  time.Time
+}
+
+// reproducibleFuncs returns the functions replacing the nondeterministic ones
+// in testing mode, the clock fixed at now.
+func reproducibleFuncs(testing Testing, now time.Time) template.FuncMap {
+	s := seeded{next: deterministicSequence(testing.Seed), now: now}
+	fixed := func() time.Time { return now }
+	env := environ(testing.Environment)
+	keyNext := funcmap.KeySequencer()
+	funcs := template.FuncMap{
+		"command_line":  func() string { return "testing" },
+		"now":           fixed,
+		"started":       fixed,
+		"pause":         s.pause,
+		"ago":           s.ago,
+		"rand":          s.next,
+		"randInt":       s.randInt,
+		"randAlpha":     s.chars(alphaChars),
+		"randAlphaNum":  s.chars(alphaChars + numericChars),
+		"randNumeric":   s.chars(numericChars),
+		"randAscii":     s.chars(asciiChars),
+		"randBytes":     s.randBytes,
+		"uuidv4":        s.uuidv4,
+		"shuffle":       s.shuffle,
+		"genPrivateKey": s.genPrivateKey,
+		"keys":          sortedKeys,
+		"values":        sortedValues,
+		"next":          funcmap.Sequencer(),
+		"keynext":       keyNext,
+		"keyNext":       keyNext,
+		"env":           env.get,
+		"environment":   env.get,
+		"expandenv":     env.expand,
+	}
+	for _, name := range irreproducible {
+		funcs[name] = unreproducible(name)
+	}
+	return funcs
+}
+
+// unreproducible returns the stand-in for the function name in testing mode,
+// which fails.
+func unreproducible(name string) func(...any) (string, error) {
+	return func(...any) (string, error) {
+		return "", errors.New(name + " cannot render reproducibly in testing mode")
+	}
+}
+
+// environ is the environment testing mode's environment functions read.
+type environ environment.Variables
+
+// get returns the value of the variable name, or nothing when it is unset.
+func (e environ) get(name string) string {
+	return e[name]
+}
+
+// expand returns text with its $name and ${name} variables replaced by their
+// values, as Sprig's expandenv does.
+func (e environ) expand(text string) string {
+	return os.Expand(text, e.get)
+}
+
+// bytes returns count bytes drawn from the sequence.
+func (s seeded) bytes(count int) []byte {
+	drawn := make([]byte, count)
+	var word [8]byte
+	for i := range drawn {
+		if i%len(word) == 0 {
+			binary.LittleEndian.PutUint64(word[:], uint64(s.next()))
+		}
+		drawn[i] = word[i%len(word)]
+	}
+	return drawn
+}
+
+// intn returns the next value of the sequence in [0, n), for n > 0.
+func (s seeded) intn(n int) int {
+	return int(uint64(s.next()) % uint64(n))
+}
+
+// pause sleeps for t milliseconds, as funcmap's pause does, and returns the
+// fixed time.
+func (s seeded) pause(t int64) time.Time {
+	time.Sleep(time.Duration(t) * time.Millisecond)
+	return s.now
+}
+
+// ago returns how long before the fixed time date was, to the second, as
+// Sprig's ago does of the real time: date is a time, or seconds since the
+// epoch.
+func (s seeded) ago(date any) string {
+	at := s.now
+	switch date := date.(type) {
+	case time.Time:
+		at = date
+	case int64:
+		at = time.Unix(date, 0)
+	case int:
+		at = time.Unix(int64(date), 0)
+	}
+	return s.now.Sub(at).Round(time.Second).String()
+}
+
+// randInt returns an integer in [low, high).
+func (s seeded) randInt(low, high int) (int, error) {
+	if high <= low {
+		return 0, fmt.Errorf("randInt needs a range: %d is not above %d", high, low)
+	}
+	return low + s.intn(high-low), nil
+}
+
+// chars returns a function returning count characters of chars.
+func (s seeded) chars(chars string) func(count int) string {
+	return func(count int) string {
+		drawn := make([]byte, max(count, 0))
+		for i := range drawn {
+			drawn[i] = chars[s.intn(len(chars))]
+		}
+		return string(drawn)
+	}
+}
+
+// randBytes returns count bytes, base64 encoded, as Sprig's randBytes does.
+func (s seeded) randBytes(count int) (string, error) {
+	if count < 0 {
+		return "", fmt.Errorf("randBytes needs a count: %d is negative", count)
+	}
+	return base64.StdEncoding.EncodeToString(s.bytes(count)), nil
+}
+
+// uuidv4 returns a version 4 UUID.
+func (s seeded) uuidv4() string {
+	u := s.bytes(16)
+	u[6] = u[6]&0x0f | 0x40
+	u[8] = u[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", u[0:4], u[4:6], u[6:8], u[8:10], u[10:])
+}
+
+// shuffle returns the characters of text in a shuffled order.
+func (s seeded) shuffle(text string) string {
+	runes := []rune(text)
+	for i := len(runes) - 1; i > 0; i-- {
+		j := s.intn(i + 1)
+		runes[i], runes[j] = runes[j], runes[i]
+	}
+	return string(runes)
+}
+
+// genPrivateKey returns a PEM-encoded private key of the type typ, as Sprig's
+// genPrivateKey does: RSA, the default, ECDSA, or Ed25519. DSA keys cannot be
+// generated reproducibly.
+func (s seeded) genPrivateKey(typ string) (string, error) {
+	var (
+		block *pem.Block
+		err   error
+	)
+	switch typ {
+	case "", "rsa":
+		block, err = s.rsaKey()
+	case "ecdsa":
+		block, err = s.ecdsaKey()
+	case "ed25519":
+		block, err = s.ed25519Key()
+	default:
+		return "", fmt.Errorf("genPrivateKey cannot generate %q keys reproducibly in testing mode", typ)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to generate private key: %w", err)
+	}
+	return string(pem.EncodeToMemory(block)), nil
+}
+
+// rsaKey returns an RSA key from primes drawn from the sequence. Go's key
+// generation ignores any source of randomness but its own, so the primes are
+// searched for here.
+func (s seeded) rsaKey() (*pem.Block, error) {
+	for {
+		p, q := s.prime(rsaBits/2), s.prime(rsaBits/2)
+		one := big.NewInt(1)
+		totient := new(big.Int).Mul(new(big.Int).Sub(p, one), new(big.Int).Sub(q, one))
+		d := new(big.Int).ModInverse(big.NewInt(rsaExponent), totient)
+		if d == nil || p.Cmp(q) == 0 {
+			continue
+		}
+		key := &rsa.PrivateKey{
+			PublicKey: rsa.PublicKey{N: new(big.Int).Mul(p, q), E: rsaExponent},
+			D:         d,
+			Primes:    []*big.Int{p, q},
+		}
+		key.Precompute()
+		if err := key.Validate(); err != nil {
+			return nil, err
+		}
+		return &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}, nil
+	}
+}
+
+// prime returns the first prime from a number of bits bits drawn from the
+// sequence, its top two bits set so the product of two is never a bit short.
+func (s seeded) prime(bits int) *big.Int {
+	drawn := s.bytes(bits / 8)
+	drawn[0] |= 0xc0
+	drawn[len(drawn)-1] |= 1
+	candidate := new(big.Int).SetBytes(drawn)
+	for two := big.NewInt(2); !candidate.ProbablyPrime(20); {
+		candidate.Add(candidate, two)
+	}
+	return candidate
+}
+
+// ecdsaKey returns a P-256 ECDSA key whose scalar is drawn from the sequence.
+func (s seeded) ecdsaKey() (*pem.Block, error) {
+	curve := elliptic.P256()
+	for {
+		key, err := ecdsa.ParseRawPrivateKey(curve, s.bytes((curve.Params().BitSize+7)/8))
+		if err != nil {
+			continue
+		}
+		der, err := x509.MarshalECPrivateKey(key)
+		return &pem.Block{Type: "EC PRIVATE KEY", Bytes: der}, err
+	}
+}
+
+// ed25519Key returns an Ed25519 key whose seed is drawn from the sequence.
+func (s seeded) ed25519Key() (*pem.Block, error) {
+	der, err := x509.MarshalPKCS8PrivateKey(ed25519.NewKeyFromSeed(s.bytes(ed25519.SeedSize)))
+	return &pem.Block{Type: "PRIVATE KEY", Bytes: der}, err
+}
+
+// sortedKeys returns the keys of dicts, as Sprig's keys does, but sorted
+// rather than in map order.
+func sortedKeys(dicts ...map[string]any) []string {
+	keys := []string{}
+	for _, dict := range dicts {
+		keys = append(keys, slices.Collect(maps.Keys(dict))...)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// sortedValues returns the values of dict, as Sprig's values does, but in the
+// order of their keys rather than in map order.
+func sortedValues(dict map[string]any) []any {
+	values := []any{}
+	for _, key := range slices.Sorted(maps.Keys(dict)) {
+		values = append(values, dict[key])
+	}
+	return values
+}
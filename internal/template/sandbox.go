@@ -0,0 +1,105 @@
+package template
+
+import (
+	"slices"
+	"text/template"
+)
+
+// sandboxed is the allowlist of functions a sandboxed template may call: the
+// ones whose result depends on their arguments alone. Left out are the
+// functions that read the environment (env, expandenv, environment,
+// command_line), the clock (now, ago, started, and the date functions, which
+// read the local time zone), randomness (rand*, shuffle, uuidv4, bcrypt,
+// htpasswd, encryptAES, and the key and certificate generators), the network
+// (getHostByName), or state shared between renders (next, keyNext, pause,
+// debug and its toggles).
+var sandboxed = []string{
+	// Strings.
+	"abbrev", "abbrevboth", "camelcase", "cat", "cleanse", "cleanser", "contains",
+	"hasPrefix", "hasSuffix", "hello", "identifier", "indent", "initcap", "initials",
+	"join", "kebabcase", "lower", "nindent", "nospace", "plural", "quote", "repeat",
+	"replace", "replace_", "snakecase", "sortAlpha", "split", "splitList", "splitn",
+	"squote", "substr", "swapcase", "title", "toLower", "toString", "toStrings",
+	"toUpper", "trim", "trimAll", "trimLeft", "trimLeft_", "trimPrefix", "trimRight",
+	"trimRight_", "trimSuffix", "trim_", "trim_left", "trim_left_", "trim_right",
+	"trim_right_", "trimall", "trunc", "untitle", "upper", "wrap", "wrapWith",
+	// Regular expressions.
+	"mustRegexFind", "mustRegexFindAll", "mustRegexMatch", "mustRegexReplaceAll",
+	"mustRegexReplaceAllLiteral", "mustRegexSplit", "regexFind", "regexFindAll",
+	"regexMatch", "regexQuoteMeta", "regexReplaceAll", "regexReplaceAllLiteral",
+	"regexSplit",
+	// Numbers.
+	"DecToInt", "FromInt", "HexToInt", "ToInt", "add", "add1", "add1f", "addf",
+	"atoi", "biggest", "ceil", "dec_to_int", "div", "div_", "divf", "float64",
+	"floor", "from_int", "hex_to_int", "inc", "int", "int64", "max", "maxf", "min",
+	"minf", "mod", "mul", "mulf", "round", "seq", "sub", "subf", "toDecimal",
+	"to_int", "until", "untilStep",
+	// Lists.
+	"append", "chunk", "compact", "concat", "first", "has", "iindex", "initial",
+	"last", "list", "mustAppend", "mustChunk", "mustCompact", "mustFirst", "mustHas",
+	"mustInitial", "mustLast", "mustPrepend", "mustPush", "mustRest", "mustReverse",
+	"mustSlice", "mustUniq", "mustWithout", "prepend", "push", "rest", "reverse",
+	"slice", "tuple", "uniq", "without",
+	// Dictionaries.
+	"deepCopy", "dict", "dig", "get", "hasKey", "keys", "merge", "mergeOverwrite",
+	"mustDeepCopy", "mustMerge", "mustMergeOverwrite", "omit", "pick", "pluck",
+	"set", "unset", "values",
+	// Encodings and digests.
+	"adler32sum", "b32dec", "b32enc", "b64dec", "b64enc", "fromJson", "mustFromJson",
+	"mustToJson", "mustToPrettyJson", "mustToRawJson", "sha1sum", "sha256sum",
+	"sha512sum", "toJson", "toPrettyJson", "toRawJson",
+	// Logic, defaults, and types.
+	"all", "any", "coalesce", "deepEqual", "default", "empty", "fail", "kindIs",
+	"kindOf", "ternary", "typeIs", "typeIsLike", "typeOf",
+	// Durations.
+	"duration", "durationRound",
+	// Paths, URLs, and versions.
+	"base", "basename", "clean", "dir", "dirname", "ext", "isAbs", "osBase", "osClean",
+	"osDir", "osExt", "osIsAbs", "semver", "semverCompare", "urlJoin", "urlParse",
+	// Templates.
+	"include", "required", "tpl",
+	// Network addresses.
+	"CIDRNext", "IP4Add", "IP4Inc", "IP4Join", "IP4Next", "IP4Prev", "IP6Add",
+	"IP6Inc", "IP6Join", "IP6Next", "IP6Prev", "IPInts", "IPMath", "IPSplit",
+	"cidr_next", "ip4_add", "ip4_inc", "ip4_join", "ip4_next", "ip4_prev", "ip6_add",
+	"ip6_inc", "ip6_join", "ip6_next", "ip6_prev", "ip_ints", "ip_math", "ip_split",
+}
+
+type (
+	// SandboxEnabled restricts a function set to the sandboxed allowlist.
+	SandboxEnabled bool
+	// FuncNames names template functions.
+	FuncNames []string
+)
+
+// FuncPolicy selects the functions of a set a template may call. A sandboxed
+// policy keeps the allowlisted functions and those Allow names; any policy
+// removes those Deny names, which win over Allow.
+type FuncPolicy struct {
+	Allow       FuncNames
+	Deny        FuncNames
+	IsSandboxed SandboxEnabled
+}
+
+// Apply returns the functions of funcs the policy keeps, leaving funcs
+// untouched.
+func (p FuncPolicy) Apply(funcs template.FuncMap) template.FuncMap {
+	kept := template.FuncMap{}
+	for name, fn := range funcs {
+		if p.keeps(name) {
+			kept[name] = fn
+		}
+	}
+	return kept
+}
+
+// keeps reports whether the policy keeps the function name.
+func (p FuncPolicy) keeps(name string) bool {
+	switch {
+	case slices.Contains(p.Deny, name):
+		return false
+	case !bool(p.IsSandboxed):
+		return true
+	}
+	return slices.Contains(sandboxed, name) || slices.Contains(p.Allow, name)
+}
@@ -1,14 +1,15 @@
-// Package template parses and executes a single Go text/template against a
-// data context, using the gomatic/funcmap function set. It is an
-// implementation package: pure, with no IO and no CLI knowledge, so a renderer
-// is reusable anywhere a template must be evaluated.
+// Package template parses and executes a single Go text/template, or
+// html/template, against a data context, using the gomatic/funcmap function
+// set. It is an implementation package: pure, with no IO and no CLI knowledge,
+// so a renderer is reusable anywhere a template must be evaluated.
 package template
 
 import (
-	"bytes"
+	"context"
 	"fmt"
 	"maps"
 	"text/template"
+	"time"
 
 	"github.com/Masterminds/sprig/v3"
 	"github.com/gomatic/clock"
@@ -20,9 +21,6 @@ import (
 // Seed initializes the deterministic testing-mode random sequence.
 type Seed uint64
 
-// testSeed seeds the deterministic random source used in testing mode.
-const testSeed Seed = 0
-
 // splitmix64 constants for the deterministic testing-mode sequence (see
 // https://prng.di.unimi.it/splitmix64.c): a minimal, reproducible generator
 // yielding full-range int64 values without a weak math/rand source.
@@ -47,7 +45,9 @@ type (
 // an exhaustiveness check can see it: a value added here without an arm below
 // is a value NormalizeMissingKey would silently rewrite to "error".
 const (
-	missingKeyZero    MissingKey = "zero"
+	// MissingKeyZero renders a missing key as its zero value; exported for
+	// callers that must tolerate keys not yet defined.
+	MissingKeyZero    MissingKey = "zero"
 	defaultMissingKey MissingKey = "error"
 	missingKeyDefault MissingKey = "default"
 	missingKeyInvalid MissingKey = "invalid"
@@ -57,29 +57,40 @@ const (
 // option and "error" otherwise.
 func NormalizeMissingKey(key MissingKey) MissingKey {
 	switch key {
-	case missingKeyZero, defaultMissingKey, missingKeyDefault, missingKeyInvalid:
+	case MissingKeyZero, defaultMissingKey, missingKeyDefault, missingKeyInvalid:
 		return key
 	}
 	return defaultMissingKey
 }
 
 // Funcs returns a fresh function set combining the Sprig v3 library with
-// funcmap's own functions. funcmap is overlaid last so it wins on a name clash:
-// existing templates keep funcmap's signatures (e.g. the two-argument trim and
-// the reversed-argument sub/div/mod) while Sprig v3 supplies everything funcmap
-// does not define. In isTesting mode the nondeterministic functions
-// (command_line, now, started, rand) are overridden so output is reproducible.
+// funcmap's own functions. funcmap is overlaid on Sprig so it wins on a name
+// clash: existing templates keep funcmap's signatures (e.g. the two-argument
+// trim and the reversed-argument sub/div/mod) while Sprig v3 supplies
+// everything funcmap does not define. Helm's include, tpl, and required, which
+// neither defines, complete the set. In isTesting mode the nondeterministic
+// functions are overridden so output is reproducible, as FuncsWith overrides
+// them with the zero seed and clock.Format's time.
 func Funcs(isTesting TestingEnabled) template.FuncMap {
+	return FuncsWith(Testing{IsEnabled: isTesting})
+}
+
+// FuncsWith returns the function set Funcs does, reproducible in testing mode
+// from testing's seed and time: every function drawing randomness draws it
+// from one sequence seeded with the seed, every function reading the clock
+// reads the time, every function reading the environment reads testing's,
+// and the map helpers list in key order.
+func FuncsWith(testing Testing) template.FuncMap {
 	funcs := template.FuncMap{}
 	maps.Copy(funcs, sprig.TxtFuncMap())
 	maps.Copy(funcs, funcmap.New(funcmap.WithV1Map()))
-	if isTesting {
-		fixed := clock.Now(clock.Format)
-		nextRand := deterministicSequence(testSeed)
-		funcs["command_line"] = func() string { return "testing" }
-		funcs["now"] = fixed
-		funcs["started"] = fixed
-		funcs["rand"] = func() int64 { return nextRand() }
+	maps.Copy(funcs, helmFuncs())
+	if testing.IsEnabled {
+		now := testing.Now
+		if now.IsZero() {
+			now = clock.Now(clock.Format)()
+		}
+		maps.Copy(funcs, reproducibleFuncs(testing, now))
 	}
 	return funcs
 }
@@ -98,26 +109,68 @@ func deterministicSequence(seed Seed) func() int64 {
 	}
 }
 
-// Render parses source as a template named name with the given functions and
-// missingkey option, then executes it against data, returning the rendered
-// bytes. Parse and execute failures surface as distinct sentinels; a panic in a
-// template function is recovered as ErrRenderPanic rather than crashing.
-func Render(funcs template.FuncMap, missing MissingKey, name Name, source []byte, data any) (out []byte, err error) {
+// Render parses source as a template named name with the given functions,
+// missingkey option, and syntax, then executes it against data, returning the
+// rendered bytes. Parse, escape, and execute failures surface as distinct
+// sentinels, wrapped in a constants.TemplateError locating them in source and
+// suggesting the name meant; a panic in a template function is recovered as
+// ErrRenderPanic rather than crashing.
+func Render(funcs template.FuncMap, missing MissingKey, syntax Syntax, name Name, source []byte, data any) ([]byte, error) {
+	return RenderLimited(context.Background(), Limits{}, funcs, missing, syntax, name, source, data)
+}
+
+// RenderLimited renders as Render does, within limits: a render running past
+// its timeout, writing past its output cap, or ranging past its iteration cap
+// fails with ErrRenderTimeout, ErrOutputLimit, or ErrIterationLimit, and one
+// whose ctx is cancelled fails with the cause of the cancellation.
+func RenderLimited(
+	ctx context.Context,
+	limits Limits,
+	funcs template.FuncMap,
+	missing MissingKey,
+	syntax Syntax,
+	name Name,
+	source []byte,
+	data any,
+) (out []byte, err error) {
 	defer func() {
 		if recovered := recover(); recovered != nil {
 			out, err = nil, constants.ErrRenderPanic.With(nil, fmt.Sprint(recovered))
 		}
 	}()
-	parsed, err := template.New(string(name)).
-		Option("missingkey=" + string(missing)).
-		Funcs(funcs).
-		Parse(string(source))
+	parsed, err := Parse(funcs, missing, syntax, name, source)
 	if err != nil {
-		return nil, constants.ErrParseTemplate.With(err)
+		return nil, err
 	}
-	var rendered bytes.Buffer
-	if err := parsed.Execute(&rendered, data); err != nil {
-		return nil, constants.ErrExecuteTemplate.With(err)
+	bindings := &helm{funcs: funcs, source: source, syntax: syntax, name: name, missing: missing}
+	bounded, err := execute(ctx, limits, parsed, bindings, data)
+	if bounded.err != nil {
+		return nil, bounded.err
+	}
+	if err != nil {
+		return nil, Diagnose(syntax.Library.locate(executeFailure(name, source, err)), funcs, data)
+	}
+	return bounded.out.Bytes(), nil
+}
+
+// execute executes parsed against data within limits, with bindings bound to
+// it, returning the limiter holding the output and the limit failed on, if
+// any, with the execution error.
+func execute(
+	ctx context.Context,
+	limits Limits,
+	parsed Parsed,
+	bindings *helm,
+	data any,
+) (*limiter, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	if limits.Timeout > 0 {
+		ctx, cancel = context.WithTimeoutCause(ctx, time.Duration(limits.Timeout),
+			constants.ErrRenderTimeout.With(nil, "after", time.Duration(limits.Timeout)))
 	}
-	return rendered.Bytes(), nil
+	defer cancel()
+	bounded := &limiter{ctx: ctx, limits: limits}
+	bounded.instrument(parsed.Trees()...)
+	parsed.addFuncs(bindings.bind(bounded, parsed))
+	return bounded, parsed.Execute(bounded, data)
 }
@@ -1,13 +1,18 @@
 package template_test
 
 import (
+	"context"
+	"errors"
+	"strings"
 	"testing"
 	texttemplate "text/template"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/gomatic/renderizer/internal/constants"
+	"github.com/gomatic/renderizer/internal/environment"
 	"github.com/gomatic/renderizer/internal/template"
 )
 
@@ -62,18 +67,78 @@ func TestFuncsTestingRandIsDeterministic(t *testing.T) {
 	assert.Equal(t, first, second, "testing-mode rand must be reproducible across runs")
 }
 
+// TestFuncsWithIsReproducible pins testing mode's contract: every function
+// that would vary from run to run renders the same for the same seed and
+// time, draws from the seed, and reads the fixed clock.
+func TestFuncsWithIsReproducible(t *testing.T) {
+	t.Parallel()
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	source := []byte(`{{ now | date "2006-01-02" }} {{ ago (now.Add -3600e9) }} {{ rand }} ` +
+		`{{ randInt 1 100 }} {{ randAlpha 8 }} {{ randAlphaNum 8 }} {{ randNumeric 8 }} ` +
+		`{{ randAscii 8 }} {{ randBytes 8 }} {{ uuidv4 }} {{ shuffle "abcdefgh" }} ` +
+		`{{ keys .Map }} {{ values .Map }} {{ genPrivateKey "ecdsa" }} {{ genPrivateKey "ed25519" }}`)
+	data := map[string]any{"Map": map[string]any{"c": 3, "a": 1, "b": 2, "d": 4, "e": 5}}
+	render := func(seed template.Seed) string {
+		funcs := template.FuncsWith(template.Testing{Now: now, Seed: seed, IsEnabled: true})
+		got, err := template.Render(funcs, "error", template.Syntax{}, "test", source, data)
+		require.NoError(t, err)
+		return string(got)
+	}
+
+	first := render(7)
+	assert.Equal(t, first, render(7))
+	assert.NotEqual(t, first, render(8))
+	assert.True(t, strings.HasPrefix(first, "2024-01-02 1h0m0s "), first)
+	assert.Contains(t, first, "[a b c d e] [1 2 3 4 5]")
+	assert.Contains(t, first, "BEGIN EC PRIVATE KEY")
+	assert.Contains(t, first, "BEGIN PRIVATE KEY")
+	assert.Regexp(t, `[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}`, first)
+}
+
+// TestFuncsWithReadsTestingState pins that in testing mode each function set
+// counts from one, however many were built before it, and that the
+// environment functions read the environment testing mode is given.
+func TestFuncsWithReadsTestingState(t *testing.T) {
+	t.Parallel()
+	source := []byte(`{{ next }}{{ next }} {{ keynext "a" }}{{ keynext "a" }} ` +
+		`{{ env "WHO" }} {{ environment "WHO" }} {{ expandenv "hi $WHO${HOME}" }}`)
+	render := func() string {
+		funcs := template.FuncsWith(template.Testing{
+			Environment: environment.Variables{"WHO": "world"},
+			IsEnabled:   true,
+		})
+		got, err := template.Render(funcs, "error", template.Syntax{}, "test", source, nil)
+		require.NoError(t, err)
+		return string(got)
+	}
+	assert.Equal(t, "12 12 world world hi world", render())
+	assert.Equal(t, "12 12 world world hi world", render())
+}
+
+// TestFuncsWithFailsIrreproducible pins that a function testing mode cannot
+// reproduce fails the render rather than varying it.
+func TestFuncsWithFailsIrreproducible(t *testing.T) {
+	t.Parallel()
+	funcs := template.FuncsWith(template.Testing{IsEnabled: true})
+	for _, source := range []string{`{{ bcrypt "x" }}`, `{{ genCA "ca" 365 }}`, `{{ genPrivateKey "dsa" }}`} {
+		_, err := template.Render(funcs, "error", template.Syntax{}, "test", []byte(source), nil)
+		require.ErrorIs(t, err, constants.ErrExecuteTemplate, source)
+		assert.Contains(t, err.Error(), "reproducibly", source)
+	}
+}
+
 func TestRenderRecoversPanic(t *testing.T) {
 	t.Parallel()
 	// A malformed function map makes text/template's Funcs panic; Render must
 	// recover it as ErrRenderPanic rather than crash the process.
 	funcs := texttemplate.FuncMap{"bad": 42}
-	_, err := template.Render(funcs, "error", "test", []byte("hi"), nil)
+	_, err := template.Render(funcs, "error", template.Syntax{}, "test", []byte("hi"), nil)
 	require.ErrorIs(t, err, constants.ErrRenderPanic)
 }
 
 func TestFuncsIncludeSprig(t *testing.T) {
 	t.Parallel()
-	got, err := template.Render(template.Funcs(false), "error", "test", []byte(`{{ b64enc "x" }}`), nil)
+	got, err := template.Render(template.Funcs(false), "error", template.Syntax{}, "test", []byte(`{{ b64enc "x" }}`), nil)
 	require.NoError(t, err)
 	assert.Equal(t, "eA==", string(got))
 }
@@ -81,7 +146,7 @@ func TestFuncsIncludeSprig(t *testing.T) {
 func TestFuncsIncludeSprigV3(t *testing.T) {
 	t.Parallel()
 	// toRawJson exists only in Sprig v3, proving the v3 library is wired in.
-	got, err := template.Render(template.Funcs(false), "error", "test", []byte(`{{ toRawJson (list 1 2 3) }}`), nil)
+	got, err := template.Render(template.Funcs(false), "error", template.Syntax{}, "test", []byte(`{{ toRawJson (list 1 2 3) }}`), nil)
 	require.NoError(t, err)
 	assert.Equal(t, "[1,2,3]", string(got))
 }
@@ -90,7 +155,7 @@ func TestFuncsClashPrefersFuncmap(t *testing.T) {
 	t.Parallel()
 	// funcmap's trim is strings.Trim (s, cutset); sprig's trim is one-argument
 	// TrimSpace. funcmap wins, so the two-argument form is the one that resolves.
-	got, err := template.Render(template.Funcs(false), "error", "test", []byte(`{{ trim "xhix" "x" }}`), nil)
+	got, err := template.Render(template.Funcs(false), "error", template.Syntax{}, "test", []byte(`{{ trim "xhix" "x" }}`), nil)
 	require.NoError(t, err)
 	assert.Equal(t, "hi", string(got))
 }
@@ -134,7 +199,7 @@ func TestRender(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
-			got, err := template.Render(funcs, "error", "test", []byte(tt.source), tt.data)
+			got, err := template.Render(funcs, "error", template.Syntax{}, "test", []byte(tt.source), tt.data)
 			if tt.wantErr != nil {
 				require.ErrorIs(t, err, tt.wantErr)
 				return
@@ -144,3 +209,335 @@ func TestRender(t *testing.T) {
 		})
 	}
 }
+
+// TestRenderLocatesFailures pins the position a failure is reported at: the
+// template, the one-based line and column, the source line, and the field path.
+func TestRenderLocatesFailures(t *testing.T) {
+	t.Parallel()
+	for _, tt := range []struct {
+		name   string
+		source string
+		want   constants.TemplateError
+	}{
+		{
+			name:   "execute error",
+			source: "first\n  {{ .Missing }}\n",
+			want: constants.TemplateError{
+				Template: "test",
+				Source:   "  {{ .Missing }}",
+				Path:     ".Missing",
+				Reason:   `map has no entry for key "Missing"`,
+				Line:     2,
+				Column:   6,
+			},
+		},
+		{
+			name:   "parse error",
+			source: "first\nsecond {{ nope }}",
+			want: constants.TemplateError{
+				Template: "test",
+				Source:   "second {{ nope }}",
+				Reason:   `function "nope" not defined`,
+				Line:     2,
+				Column:   11,
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := template.Render(template.Funcs(false), "error", template.Syntax{}, "test", []byte(tt.source), map[string]any{})
+			var located *constants.TemplateError
+			require.ErrorAs(t, err, &located)
+			tt.want.Cause = located.Cause
+			assert.Equal(t, tt.want, *located)
+		})
+	}
+}
+
+// TestRenderSuggestsTheNameMeant pins the suggestions a failure carries: the
+// nearest key present at the failing path, or the nearest defined function,
+// and none when nothing is near enough to be a likely typo.
+func TestRenderSuggestsTheNameMeant(t *testing.T) {
+	t.Parallel()
+	data := map[string]any{
+		"Name": "x",
+		"App":  map[string]any{"Port": 80, "Host": "h"},
+		"env":  map[string]string{"HOME": "/home"},
+	}
+	for _, tt := range []struct {
+		name   string
+		source string
+		want   string
+	}{
+		{name: "transposed key", source: "{{ .Nmae }}", want: "Name"},
+		{name: "key in a nested map", source: "{{ .App.Prot }}", want: "Port"},
+		{name: "key in the environment", source: "{{ .env.HOEM }}", want: "HOME"},
+		{name: "key differing in case", source: "{{ .name }}", want: "Name"},
+		{name: "unknown function", source: "{{ uper .Name }}", want: "upper"},
+		{name: "nothing near", source: "{{ .Unrelated }}", want: ""},
+		{name: "nothing near a missing parent", source: "{{ .Nope.Name }}", want: ""},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			_, err := template.Render(template.Funcs(false), "error", template.Syntax{}, "test", []byte(tt.source), data)
+			var located *constants.TemplateError
+			require.ErrorAs(t, err, &located)
+			assert.Equal(t, tt.want, located.Suggestion)
+		})
+	}
+}
+
+// TestFuncPolicy pins what a policy keeps: the sandbox allowlist and the names
+// allowed into it, never a denied name, and without the sandbox everything not
+// denied.
+func TestFuncPolicy(t *testing.T) {
+	t.Parallel()
+	standard := template.Funcs(false)
+	sandboxed := template.FuncPolicy{IsSandboxed: true, Allow: template.FuncNames{"now"}, Deny: template.FuncNames{"upper"}}.Apply(standard)
+	assert.Contains(t, sandboxed, "lower")
+	assert.Contains(t, sandboxed, "now", "allowed into the sandbox")
+	assert.NotContains(t, sandboxed, "upper", "denied wins over the allowlist")
+	for _, removed := range []string{"env", "expandenv", "environment", "debug", "started", "randInt", "uuidv4", "getHostByName"} {
+		assert.NotContains(t, sandboxed, removed)
+	}
+
+	unsandboxed := template.FuncPolicy{Deny: template.FuncNames{"env"}}.Apply(standard)
+	assert.Len(t, unsandboxed, len(standard)-1)
+	assert.NotContains(t, unsandboxed, "env")
+}
+
+// TestRenderRejectsARemovedFunctionAtParseTime pins the clear error: a
+// standard function the policy removed fails parsing as not allowed, matching
+// ErrRemovedFunction, rather than as an unknown name to correct.
+func TestRenderRejectsARemovedFunctionAtParseTime(t *testing.T) {
+	t.Parallel()
+	funcs := template.FuncPolicy{IsSandboxed: true}.Apply(template.Funcs(false))
+	_, err := template.Render(funcs, "error", template.Syntax{}, "test", []byte(`{{ env "HOME" }}`), nil)
+	require.ErrorIs(t, err, constants.ErrParseTemplate)
+	require.ErrorIs(t, err, constants.ErrRemovedFunction)
+	var located *constants.TemplateError
+	require.ErrorAs(t, err, &located)
+	assert.Equal(t, `function "env" is not in the allowed function set`, located.Reason)
+	assert.Empty(t, located.Suggestion)
+}
+
+// TestRenderLimitedStopsARunawayTemplate pins each limit: a template running
+// past one fails with its sentinel rather than with whatever text/template
+// made of the failure, and a template within every limit renders unchanged.
+func TestRenderLimitedStopsARunawayTemplate(t *testing.T) {
+	t.Parallel()
+	for _, tc := range []struct {
+		wantErr error
+		name    string
+		source  string
+		limits  template.Limits
+	}{
+		{name: "until", source: `{{ range until 100000000 }}x{{ end }}`,
+			limits: template.Limits{MaxIterations: 1000}, wantErr: constants.ErrIterationLimit},
+		{name: "until negative", source: `{{ $list := until -2000 }}`,
+			limits: template.Limits{MaxIterations: 1000}, wantErr: constants.ErrIterationLimit},
+		{name: "untilStep counting down", source: `{{ $list := untilStep 2000 0 -1 }}`,
+			limits: template.Limits{MaxIterations: 1000}, wantErr: constants.ErrIterationLimit},
+		{name: "untilStep widest span", source: `{{ $list := untilStep -9223372036854775808 9223372036854775807 1 }}`,
+			limits: template.Limits{MaxIterations: 1000}, wantErr: constants.ErrIterationLimit},
+		{name: "integer range", source: `{{ range 100000000 }}{{ end }}`,
+			limits: template.Limits{MaxIterations: 1000}, wantErr: constants.ErrIterationLimit},
+		{name: "nested range", source: `{{ define "t" }}{{ range 40 }}{{ end }}{{ end }}{{ range 40 }}{{ template "t" }}{{ end }}`,
+			limits: template.Limits{MaxIterations: 1000}, wantErr: constants.ErrIterationLimit},
+		{name: "output", source: `{{ repeat 100000 "x" }}`,
+			limits: template.Limits{MaxOutput: 1024}, wantErr: constants.ErrOutputLimit},
+		{name: "timeout", source: `{{ range 100000000 }}x{{ end }}`,
+			limits: template.Limits{Timeout: template.Timeout(10 * time.Millisecond)}, wantErr: constants.ErrRenderTimeout},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+			out, err := template.RenderLimited(context.Background(), tc.limits,
+				template.Funcs(false), "error", template.Syntax{}, "test", []byte(tc.source), nil)
+			require.ErrorIs(t, err, tc.wantErr)
+			assert.Nil(t, out)
+		})
+	}
+
+	limits := template.Limits{Timeout: template.Timeout(time.Minute), MaxOutput: 5, MaxIterations: 3}
+	for source, want := range map[string]string{
+		`{{ range until 3 }}{{ . }}{{ end }}`:            "012",
+		`{{ range until -3 }}{{ . }}{{ end }}`:           "0-1-2",
+		`{{ range untilStep 3 0 -1 }}{{ . }}{{ end }}`:   "321",
+		`{{ range untilStep 0 100000000 -1 }}x{{ end }}`: "",
+	} {
+		out, err := template.RenderLimited(context.Background(), limits,
+			template.Funcs(false), "error", template.Syntax{}, "test", []byte(source), nil)
+		require.NoError(t, err, source)
+		assert.Equal(t, want, string(out), source)
+	}
+}
+
+// TestRenderLimitedStopsWhenCancelled pins that a cancelled render fails with
+// the cause of the cancellation, not as a timeout.
+func TestRenderLimitedStopsWhenCancelled(t *testing.T) {
+	t.Parallel()
+	stopped := errors.New("stopped")
+	ctx, cancel := context.WithCancelCause(context.Background())
+	cancel(stopped)
+	_, err := template.RenderLimited(ctx, template.Limits{},
+		template.Funcs(false), "error", template.Syntax{}, "test", []byte(`{{ range 10 }}x{{ end }}`), nil)
+	require.ErrorIs(t, err, stopped)
+	assert.NotErrorIs(t, err, constants.ErrRenderTimeout)
+}
+
+// TestParseDelims pins the --delims syntax: a comma-separated pair, trimmed,
+// with an empty spec standing for the default pair.
+func TestParseDelims(t *testing.T) {
+	t.Parallel()
+	for spec, want := range map[string]template.Delims{
+		"":         {},
+		"[[,]]":    {Left: "[[", Right: "]]"},
+		" <%, %> ": {Left: "<%", Right: "%>"},
+	} {
+		got, err := template.ParseDelims(spec)
+		require.NoError(t, err, spec)
+		assert.Equal(t, want, got, spec)
+	}
+	for _, spec := range []string{"[[", "[[,", ",]]", " , "} {
+		_, err := template.ParseDelims(spec)
+		require.ErrorIs(t, err, constants.ErrParseDelims, spec)
+	}
+}
+
+// TestRenderWithDelims pins that custom delimiters leave the default pair as
+// plain text, and that a failure is still located in the source.
+func TestRenderWithDelims(t *testing.T) {
+	t.Parallel()
+	syntax := template.Syntax{Delims: template.Delims{Left: "[[", Right: "]]"}}
+	out, err := template.Render(template.Funcs(false), "error", syntax, "test",
+		[]byte(`name: [[ .Name ]]
+run: ${{ matrix.os }}`), map[string]any{"Name": "ci"})
+	require.NoError(t, err)
+	assert.Equal(t, "name: ci\nrun: ${{ matrix.os }}", string(out))
+
+	_, err = template.Render(template.Funcs(false), "error", syntax, "test",
+		[]byte("ok\n[[ .Nmae ]]"), map[string]any{"Name": "ci"})
+	var located *constants.TemplateError
+	require.ErrorAs(t, err, &located)
+	assert.Equal(t, 2, located.Line)
+	assert.Equal(t, "Name", located.Suggestion)
+}
+
+// TestRenderHTMLEscapesForTheContext pins HTML mode: each value is escaped for
+// the context it lands in, the limits still apply, and an action that cannot
+// be escaped fails as an escape error in the parse stage, located in source.
+func TestRenderHTMLEscapesForTheContext(t *testing.T) {
+	t.Parallel()
+	syntax := template.Syntax{IsHTML: true}
+	data := map[string]any{"Name": "<script>", "Items": []string{"a", "b"}}
+	out, err := template.Render(template.Funcs(false), "error", syntax, "test",
+		[]byte(`<p>{{ .Name | upper }}</p><script>var n = {{ .Name }};</script>`), data)
+	require.NoError(t, err)
+	assert.Equal(t, `<p>&lt;SCRIPT&gt;</p><script>var n = "\u003cscript\u003e";</script>`, string(out))
+
+	_, err = template.RenderLimited(context.Background(), template.Limits{MaxIterations: 1},
+		template.Funcs(false), "error", syntax, "test", []byte(`{{ range .Items }}<i>{{ . }}</i>{{ end }}`), data)
+	require.ErrorIs(t, err, constants.ErrIterationLimit)
+
+	_, err = template.Render(template.Funcs(false), "error", syntax, "test",
+		[]byte("ok\n<a {{ if .Name }}href{{ end }}=\"x\">"), data)
+	require.ErrorIs(t, err, constants.ErrEscapeTemplate)
+	assert.Equal(t, constants.StageParse, constants.StageOf(err))
+	var located *constants.TemplateError
+	require.ErrorAs(t, err, &located)
+	assert.Equal(t, 2, located.Line)
+	assert.Equal(t, `<a {{ if .Name }}href{{ end }}="x">`, located.Source)
+}
+
+// TestRenderWithLibrary pins the library contract: its templates can be
+// invoked and their blocks overridden by each template rendered with it,
+// rendering with it leaves it as parsed, and a failure in one of its files is
+// located in that file.
+func TestRenderWithLibrary(t *testing.T) {
+	t.Parallel()
+	funcs := template.Funcs(false)
+	library, err := template.ParseLibrary(funcs, template.Delims{}, []template.LibraryFile{
+		{Name: "labels.tmpl", Source: []byte(`{{ define "labels" }}{{ range . }}[{{ . }}]{{ end }}{{ end }}`)},
+		{Name: "layout.tmpl", Source: []byte(`{{ define "layout" }}<{{ block "body" . }}default{{ end }}>{{ end }}`)},
+	})
+	require.NoError(t, err)
+	data := []string{"a", "b"}
+
+	for _, syntax := range []template.Syntax{{Library: library}, {Library: library, IsHTML: true}} {
+		for range 2 {
+			out, err := template.RenderLimited(context.Background(), template.Limits{MaxIterations: 2},
+				funcs, "error", syntax, "page", []byte(`{{ template "labels" . }}{{ template "layout" . }}`), data)
+			require.NoError(t, err, "each render counts the library's iterations afresh")
+			assert.Contains(t, string(out), "[a][b]")
+			assert.Contains(t, string(out), "default")
+		}
+	}
+
+	out, err := template.Render(funcs, "error", template.Syntax{Library: library}, "page",
+		[]byte(`{{ define "body" }}{{ len . }}{{ end }}{{ template "layout" . }}`), data)
+	require.NoError(t, err)
+	assert.Equal(t, "<2>", string(out))
+
+	_, err = template.Render(funcs, "error", template.Syntax{Library: library}, "page",
+		[]byte(`{{ template "labels" true }}`), nil)
+	var located *constants.TemplateError
+	require.ErrorAs(t, err, &located)
+	assert.Equal(t, "labels.tmpl", located.Template)
+	assert.Equal(t, `{{ define "labels" }}{{ range . }}[{{ . }}]{{ end }}{{ end }}`, located.Source)
+
+	_, err = template.ParseLibrary(funcs, template.Delims{}, []template.LibraryFile{
+		{Name: "broken.tmpl", Source: []byte("ok\n{{ define \"x\" }}{{ .Unclosed }")},
+	})
+	require.ErrorIs(t, err, constants.ErrParseTemplate)
+	require.ErrorAs(t, err, &located)
+	assert.Equal(t, "broken.tmpl", located.Template)
+	assert.Equal(t, 2, located.Line)
+}
+
+// TestRenderHelmFunctions pins Helm's functions: include renders a named
+// template into a string that can be piped on, as text even in a template
+// parsed as HTML, which escapes the string where it is printed; tpl renders a
+// string as a template, able to include the set's templates and bounded by
+// the render's limits; and required fails the render as an execute error.
+func TestRenderHelmFunctions(t *testing.T) {
+	t.Parallel()
+	funcs := template.Funcs(false)
+	data := map[string]any{"Name": "web", "Tpl": `{{ include "labels" . | upper }}`, "Empty": ""}
+	source := []byte(`{{ define "labels" }}app: {{ .Name }}{{ end }}labels:{{ include "labels" . | nindent 2 }}`)
+
+	out, err := template.Render(funcs, "error", template.Syntax{}, "page", source, data)
+	require.NoError(t, err)
+	assert.Equal(t, "labels:\n  app: web", string(out))
+
+	out, err = template.Render(funcs, "error", template.Syntax{IsHTML: true}, "page",
+		[]byte(`{{ define "b" }}<b>{{ .Name }}</b>{{ end }}<p>{{ include "b" . }}</p>`), data)
+	require.NoError(t, err)
+	assert.Equal(t, "<p>&lt;b&gt;web&lt;/b&gt;</p>", string(out))
+
+	out, err = template.Render(funcs, "error", template.Syntax{}, "page",
+		[]byte(`{{ define "labels" }}app: {{ .Name }}{{ end }}{{ tpl .Tpl . }}`), data)
+	require.NoError(t, err)
+	assert.Equal(t, "APP: WEB", string(out))
+
+	_, err = template.RenderLimited(context.Background(), template.Limits{MaxIterations: 5}, funcs, "error",
+		template.Syntax{}, "page", []byte(`{{ tpl "{{ range 10 }}.{{ end }}" . }}`), data)
+	require.ErrorIs(t, err, constants.ErrIterationLimit)
+
+	_, err = template.Render(funcs, "error", template.Syntax{}, "page",
+		[]byte(`{{ define "loop" }}{{ include "loop" . }}{{ end }}{{ include "loop" . }}`), data)
+	require.ErrorIs(t, err, constants.ErrNestingLimit)
+	assert.Contains(t, err.Error(), "loop nested more than 100 deep")
+	assert.Less(t, len(err.Error()), 256, "runaway recursion reports one flat error")
+
+	out, err = template.Render(funcs, "error", template.Syntax{}, "page",
+		[]byte(`{{ required "Name is required" .Name }}`), data)
+	require.NoError(t, err)
+	assert.Equal(t, "web", string(out))
+
+	_, err = template.Render(funcs, "error", template.Syntax{}, "page",
+		[]byte(`{{ required "Empty is required" .Empty }}`), data)
+	require.ErrorIs(t, err, constants.ErrExecuteTemplate)
+	var located *constants.TemplateError
+	require.ErrorAs(t, err, &located)
+	assert.Contains(t, located.Reason, "Empty is required")
+}
@@ -1,10 +1,9 @@
 package variables
 
 import (
+	"errors"
 	"strings"
 
-	"dario.cat/mergo"
-
 	"github.com/gomatic/renderizer/internal/constants"
 )
 
@@ -26,67 +25,118 @@ type (
 )
 
 // Assignments builds a Context from the ordered assignment tokens produced by
-// Tokenize. Each `--name=value` becomes a (possibly nested) entry; a bare
-// `--name` becomes boolean true; and each -C flips capitalization of the names
-// that follow. Repeated names append into a slice, then single-element slices
-// collapse back to scalars so a name given once is a scalar.
-func Assignments(tokens []string, shouldCapitalize Capitalization, format TimeFormat) (Context, error) {
+// Tokenize. Each `--name=value` becomes a (possibly nested) entry, typed as
+// typing says or as an explicit suffix on the name demands (`--zip:string=02134`);
+// a bare `--name` becomes boolean true; and each -C flips capitalization of the
+// names that follow. Repeated names combine under strategy — appending into a
+// slice by default — then single-element slices collapse back to scalars so a
+// name given once is a scalar. A value that is not of its explicit type fails
+// with ErrTypeVariable.
+func Assignments(
+	tokens []string,
+	shouldCapitalize Capitalization,
+	typing Typing,
+	strategy MergeStrategy,
+) (Context, error) {
 	global := map[string]any{}
 	for _, token := range tokens {
 		if token == capitalizeToggle {
 			shouldCapitalize = !shouldCapitalize
 			continue
 		}
-		entry := assignment(assignmentToken(token), shouldCapitalize, format)
-		if err := mergo.Merge(&global, entry, mergo.WithAppendSlice); err != nil {
-			return nil, constants.ErrMergeContext.With(err)
+		entry, err := assignment(assignmentToken(token), shouldCapitalize, typing)
+		if err != nil {
+			return nil, err
+		}
+		if err := Merge(global, entry, strategy, nil); err != nil {
+			return nil, err
+		}
+	}
+	// Every leaf is already typed; retyping only collapses and widens.
+	return Retype(global, typing.Format, true, false), nil
+}
+
+// Path is the variable path one assignment token sets, after capitalization,
+// with the token and its position among the assignments for attribution. A
+// path into a list stops at the list, which is attributed as a whole.
+type Path struct {
+	Token string
+	Names []string
+	Index int
+}
+
+// Paths returns the path each assignment token sets, honoring -C toggles
+// exactly as Assignments does, so a caller can attribute merged values to the
+// argument that supplied them. Toggles themselves set nothing and are omitted,
+// as are malformed names, which Assignments rejects.
+func Paths(tokens []string, shouldCapitalize Capitalization) []Path {
+	paths := make([]Path, 0, len(tokens))
+	for i, token := range tokens {
+		if token == capitalizeToggle {
+			shouldCapitalize = !shouldCapitalize
+			continue
+		}
+		name, _ := splitAssignment(assignmentToken(token))
+		path, err := parsePath(name, shouldCapitalize)
+		if err != nil {
+			continue
 		}
+		paths = append(paths, Path{Token: token, Names: keys(path), Index: i})
 	}
-	return Retype(global, format, true), nil
+	return paths
 }
 
 // assignment parses one `--name=value` (or bare `--name`) token into a single
-// nested map whose leaf is a one-element slice, so repeats append on merge.
-func assignment(token assignmentToken, shouldCapitalize Capitalization, format TimeFormat) map[string]any {
+// nested map whose leaf is a one-element slice, so repeats combine on merge.
+func assignment(token assignmentToken, shouldCapitalize Capitalization, typing Typing) (map[string]any, error) {
 	name, value := splitAssignment(token)
-	path := casedPath(name, shouldCapitalize)
-	return nest(path, []any{leaf(value, format)})
+	path, err := parsePath(name, shouldCapitalize)
+	if err != nil {
+		return nil, err
+	}
+	typedValue, err := leaf(value, typing)
+	if err != nil {
+		return nil, constants.ErrTypeVariable.With(err, token)
+	}
+	return nest(path, []any{typedValue}), nil
 }
 
-// rawValue is one assignment's raw value: its text and whether the token
-// carried a value at all — a bare `--name` has none, signaling a boolean.
+// rawValue is one assignment's raw value: its text, the type its name's suffix
+// demands (if any), and whether the token carried a value at all — a bare
+// `--name` has none, signaling a boolean.
 type rawValue struct {
 	text  string
+	kind  valueType
 	isSet bool
 }
 
+// The separators of an assignment: name from value, and name from an explicit
+// type suffix.
+const (
+	valueSeparator = '='
+	typeSeparator  = ':'
+)
+
 // splitAssignment strips leading dashes and separates name from an optional
-// value. A token without `=` yields an unset value, signaling a boolean.
+// `:type` suffix and an optional value; a quoted key may contain either
+// separator. A token without `=` yields an unset value, signaling a boolean.
 func splitAssignment(token assignmentToken) (variableName, rawValue) {
 	body := strings.TrimLeft(string(token), "-")
-	name, value, found := strings.Cut(body, "=")
-	return variableName(name), rawValue{text: value, isSet: found}
+	name, value, found := cutUnquoted(body, valueSeparator)
+	name, kind, _ := cutUnquoted(name, typeSeparator)
+	return variableName(name), rawValue{text: value, kind: valueType(kind), isSet: found}
 }
 
-// leaf types a present value or yields boolean true for a bare name.
-func leaf(value rawValue, format TimeFormat) any {
+// leaf types a present value, or yields boolean true for a bare name — which
+// only a boolean suffix, if any, permits.
+func leaf(value rawValue, typing Typing) (any, error) {
 	if !value.isSet {
-		return true
-	}
-	return typed(format, rawText(value.text))
-}
-
-// casedPath splits a dotted name into segments, title-casing each when
-// capitalization is enabled.
-func casedPath(name variableName, shouldCapitalize Capitalization) []string {
-	segments := strings.Split(string(name), ".")
-	if !bool(shouldCapitalize) {
-		return segments
-	}
-	for i, segment := range segments {
-		segments[i] = title(nameSegment(segment))
+		if value.kind != inferredType && value.kind != boolType {
+			return nil, errors.New("a " + string(value.kind) + " variable needs a value")
+		}
+		return true, nil
 	}
-	return segments
+	return explicit(value.kind, rawText(value.text), typing)
 }
 
 // title upper-cases the first rune and lower-cases the rest, matching the
@@ -98,18 +148,26 @@ func title(segment nameSegment) string {
 	return strings.ToUpper(string(segment[:1])) + strings.ToLower(string(segment[1:]))
 }
 
-// nest builds a map nesting leaf under the given path of keys.
-func nest(path []string, value any) map[string]any {
-	root := map[string]any{}
-	current := root
-	for i, key := range path {
-		if i == len(path)-1 {
-			current[key] = value
-			break
-		}
-		next := map[string]any{}
-		current[key] = next
-		current = next
+// nest builds the value path sets to value: a map per key and, per subscript,
+// a list holding the value at its index or appended — marked so the merge
+// places it there rather than combining lists by strategy.
+func nest(path []segment, value any) map[string]any {
+	for i := len(path) - 1; i > 0; i-- {
+		value = step(path[i], value)
+	}
+	return map[string]any{path[0].key: value}
+}
+
+// step wraps value in the container one path segment denotes.
+func step(at segment, value any) any {
+	switch at.kind {
+	case indexSegment:
+		list := make(indexedList, at.index+1)
+		list[at.index] = value
+		return list
+	case appendSegment:
+		return appendedList{value}
+	case keySegment, quotedSegment:
 	}
-	return root
+	return map[string]any{at.key: value}
 }
@@ -1,6 +1,7 @@
 package variables_test
 
 import (
+	"os"
 	"testing"
 	"time"
 
@@ -13,6 +14,8 @@ import (
 
 const timeFormat = variables.TimeFormat("20060102T150405")
 
+var typing = variables.Typing{Format: timeFormat, ShouldInfer: true}
+
 func TestAssignments(t *testing.T) {
 	t.Parallel()
 	tests := []struct {
@@ -62,16 +65,33 @@ func TestAssignments(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
-			got, err := variables.Assignments(tt.tokens, tt.capitalize, timeFormat)
+			got, err := variables.Assignments(tt.tokens, tt.capitalize, typing, variables.MergeAppend)
 			require.NoError(t, err)
 			assert.Equal(t, tt.want, got)
 		})
 	}
 }
 
+func TestAssignmentsRepeatsFollowTheStrategy(t *testing.T) {
+	t.Parallel()
+	tokens := []string{"--items=a", "--items=b", "--items=a"}
+
+	appended, err := variables.Assignments(tokens, true, typing, variables.MergeAppend)
+	require.NoError(t, err)
+	assert.Equal(t, variables.Context{"Items": []any{"a", "b", "a"}}, appended)
+
+	unique, err := variables.Assignments(tokens, true, typing, variables.MergeUniqueAppend)
+	require.NoError(t, err)
+	assert.Equal(t, variables.Context{"Items": []any{"a", "b"}}, unique)
+
+	replaced, err := variables.Assignments(tokens, true, typing, variables.MergeReplace)
+	require.NoError(t, err)
+	assert.Equal(t, variables.Context{"Items": "a"}, replaced, "the last repeat wins and collapses to a scalar")
+}
+
 func TestAssignmentsEmptySegment(t *testing.T) {
 	t.Parallel()
-	got, err := variables.Assignments([]string{"--a..b=x"}, true, timeFormat)
+	got, err := variables.Assignments([]string{"--a..b=x"}, true, typing, variables.MergeAppend)
 	require.NoError(t, err)
 	want := variables.Context{"A": map[string]any{"": map[string]any{"B": "x"}}}
 	assert.Equal(t, want, got)
@@ -79,14 +99,199 @@ func TestAssignmentsEmptySegment(t *testing.T) {
 
 func TestAssignmentsMergeError(t *testing.T) {
 	t.Parallel()
-	_, err := variables.Assignments([]string{"--a.b=2", "--a=1"}, true, timeFormat)
+	_, err := variables.Assignments([]string{"--a.b=2", "--a=1"}, true, typing, variables.MergeAppend)
 	require.ErrorIs(t, err, constants.ErrMergeContext)
 }
 
 func TestAssignmentsTimeValue(t *testing.T) {
 	t.Parallel()
-	got, err := variables.Assignments([]string{"--when=20231225T120000"}, true, timeFormat)
+	got, err := variables.Assignments([]string{"--when=20231225T120000"}, true, typing, variables.MergeAppend)
 	require.NoError(t, err)
 	want := time.Date(2023, 12, 25, 12, 0, 0, 0, time.UTC)
 	assert.Equal(t, want, got["When"])
 }
+
+func TestAssignmentsExplicitTypes(t *testing.T) {
+	t.Parallel()
+	read := func(name string) ([]byte, error) {
+		if name == "cfg.yaml" {
+			return []byte("replicas: 3\nname: '007'\n"), nil
+		}
+		return nil, os.ErrNotExist
+	}
+	tokens := []string{
+		"--zip:string=02134",
+		"--version:string=1.10",
+		"--port:int=80",
+		"--ratio:float=1",
+		"--debug:bool",
+		"--on:bool=false",
+		"--when:time=20231225T120000",
+		`--tags:json=["a","1"]`,
+		`--one:json=["only"]`,
+		"--cfg:yaml=@cfg.yaml",
+		"--plain=02134",
+	}
+
+	got, err := variables.Assignments(tokens, true, variables.Typing{Read: read, Format: timeFormat, ShouldInfer: true}, variables.MergeAppend)
+
+	require.NoError(t, err)
+	assert.Equal(t, variables.Context{
+		"Zip":     "02134",
+		"Version": "1.10",
+		"Port":    int64(80),
+		"Ratio":   1.0,
+		"Debug":   true,
+		"On":      false,
+		"When":    time.Date(2023, 12, 25, 12, 0, 0, 0, time.UTC),
+		"Tags":    []any{"a", "1"},
+		"One":     []any{"only"},
+		"Cfg":     map[string]any{"replicas": int64(3), "name": "007"},
+		"Plain":   int64(2134),
+	}, got)
+}
+
+func TestAssignmentsWithoutInferenceKeepStrings(t *testing.T) {
+	t.Parallel()
+	got, err := variables.Assignments(
+		[]string{"--zip=02134", "--on=true", "--port:int=80", "--flag"},
+		true,
+		variables.Typing{Format: timeFormat},
+		variables.MergeAppend,
+	)
+
+	require.NoError(t, err)
+	assert.Equal(t, variables.Context{"Zip": "02134", "On": "true", "Port": int64(80), "Flag": true}, got)
+}
+
+// TestAssignmentsTypeErrors pins that a value not of its declared type fails
+// naming the token, rather than quietly falling back to a string.
+func TestAssignmentsTypeErrors(t *testing.T) {
+	t.Parallel()
+	read := func(string) ([]byte, error) { return nil, os.ErrNotExist }
+	for _, token := range []string{
+		"--port:int=eighty",
+		"--ratio:float=half",
+		"--on:bool=maybe",
+		"--when:time=yesterday",
+		"--tags:json=[a, b]",
+		"--cfg:yaml=@missing.yaml",
+		"--cfg:yaml=[",
+		"--name:str=x",
+		"--port:int",
+	} {
+		t.Run(token, func(t *testing.T) {
+			t.Parallel()
+			_, err := variables.Assignments([]string{token}, true, variables.Typing{Read: read, Format: timeFormat}, variables.MergeAppend)
+			require.ErrorIs(t, err, constants.ErrTypeVariable)
+			assert.Contains(t, err.Error(), token)
+		})
+	}
+}
+
+func TestAssignmentsListPaths(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		want   variables.Context
+		name   string
+		tokens []string
+	}{
+		{
+			name:   "indexed maps",
+			tokens: []string{"--containers[0].name=web", "--containers[1].name=db", "--containers[0].port=80"},
+			want: variables.Context{"Containers": []any{
+				map[string]any{"Name": "web", "Port": int64(80)},
+				map[string]any{"Name": "db"},
+			}},
+		},
+		{
+			name:   "a single index is still a list",
+			tokens: []string{"--containers[0].name=web"},
+			want:   variables.Context{"Containers": []any{map[string]any{"Name": "web"}}},
+		},
+		{
+			name:   "unset positions are nil",
+			tokens: []string{"--ports[2]=443"},
+			want:   variables.Context{"Ports": []any{nil, nil, int64(443)}},
+		},
+		{
+			name:   "append",
+			tokens: []string{"--args[]=-v", "--args[]=-x", "--hosts[].name=a"},
+			want: variables.Context{
+				"Args":  []any{"-v", "-x"},
+				"Hosts": []any{map[string]any{"Name": "a"}},
+			},
+		},
+		{
+			name:   "append then index into the appended element",
+			tokens: []string{"--c[].name=a", "--c[0].image=x"},
+			want:   variables.Context{"C": []any{map[string]any{"Name": "a", "Image": "x"}}},
+		},
+		{
+			name:   "nested indices",
+			tokens: []string{"--grid[1][0]=x"},
+			want:   variables.Context{"Grid": []any{nil, []any{"x"}}},
+		},
+		{
+			name:   "quoted keys keep dots and case",
+			tokens: []string{`--labels."app.kubernetes.io/name"=web`, `--labels."a=b:c"=x`, `--"x.y":int=1`},
+			want: variables.Context{
+				"Labels": map[string]any{"app.kubernetes.io/name": "web", "a=b:c": "x"},
+				"x.y":    int64(1),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			got, err := variables.Assignments(tt.tokens, true, typing, variables.MergeReplace)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestAssignmentsListPathErrors(t *testing.T) {
+	t.Parallel()
+	for _, tt := range []struct {
+		want  error
+		token string
+	}{
+		{token: "--a[x]=1", want: constants.ErrParseVariable},
+		{token: "--a[-1]=1", want: constants.ErrParseVariable},
+		{token: "--a[100000000]=1", want: constants.ErrParseVariable},
+		{token: "--a[0=1", want: constants.ErrParseVariable},
+		{token: `--"a=1`, want: constants.ErrParseVariable},
+		{token: `--"a"b=1`, want: constants.ErrParseVariable},
+	} {
+		t.Run(tt.token, func(t *testing.T) {
+			t.Parallel()
+			_, err := variables.Assignments([]string{tt.token}, true, typing, variables.MergeAppend)
+			require.ErrorIs(t, err, tt.want)
+		})
+	}
+
+	_, err := variables.Assignments([]string{"--a=1", "--a[0]=2"}, true, typing, variables.MergeAppend)
+	assert.NoError(t, err, "a scalar given once is a list of one, so it can be indexed")
+	_, err = variables.Assignments([]string{"--a.b=1", "--a[0]=2"}, true, typing, variables.MergeAppend)
+	require.ErrorIs(t, err, constants.ErrMergeContext)
+}
+
+func TestPaths(t *testing.T) {
+	t.Parallel()
+	got := variables.Paths([]string{"--name=x", "-C", "--a.b=y", "--flag"}, true)
+	assert.Equal(t, []variables.Path{
+		{Token: "--name=x", Names: []string{"Name"}, Index: 0},
+		{Token: "--a.b=y", Names: []string{"a", "b"}, Index: 2},
+		{Token: "--flag", Names: []string{"flag"}, Index: 3},
+	}, got)
+
+	typed := variables.Paths([]string{"--zip:string=02134"}, true)
+	assert.Equal(t, []string{"Zip"}, typed[0].Names, "the type suffix is not part of the name")
+
+	listed := variables.Paths([]string{"--c[0].name=x", `--labels."a.b"=y`, "--bad[=z"}, true)
+	assert.Equal(t, []variables.Path{
+		{Token: "--c[0].name=x", Names: []string{"C"}, Index: 0},
+		{Token: `--labels."a.b"=y`, Names: []string{"Labels", "a.b"}, Index: 1},
+	}, listed, "a path stops at a list, and a malformed one is omitted")
+}
@@ -0,0 +1,61 @@
+package variables
+
+import (
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// environmentSeparator separates the steps of a path in an environment
+// variable name, which cannot contain dots: `ITEMS__0__NAME` is items[0].name.
+const environmentSeparator = "__"
+
+// Environment builds a Context from environment variables named as paths:
+// `__` nests, a numeric step indexes a list, and every other step is a key,
+// title-cased when shouldCapitalize is set, exactly as an assignment's would
+// be. Values are typed as assignment values are. A name that is both a value
+// and a parent of others fails with ErrMergeContext.
+func Environment(variables map[string]string, shouldCapitalize Capitalization, typing Typing) (Context, error) {
+	global := map[string]any{}
+	for _, name := range slices.Sorted(maps.Keys(variables)) {
+		value, err := leaf(rawValue{text: variables[name], isSet: true}, typing)
+		if err != nil {
+			return nil, err
+		}
+		entry := nest(environmentPath(name, shouldCapitalize), []any{value})
+		if err := Merge(global, entry, MergeReplace, nil); err != nil {
+			return nil, err
+		}
+	}
+	return Retype(global, typing.Format, true, false), nil
+}
+
+// EnvironmentPaths returns the path each environment variable sets, sorted by
+// name, with the name as the Token, so a caller can attribute the values
+// Environment builds.
+func EnvironmentPaths(variables map[string]string, shouldCapitalize Capitalization) []Path {
+	paths := make([]Path, 0, len(variables))
+	for i, name := range slices.Sorted(maps.Keys(variables)) {
+		paths = append(paths, Path{Token: name, Names: keys(environmentPath(name, shouldCapitalize)), Index: i})
+	}
+	return paths
+}
+
+// environmentPath splits an environment variable name into path segments.
+func environmentPath(name string, shouldCapitalize Capitalization) []segment {
+	steps := strings.Split(name, environmentSeparator)
+	path := make([]segment, len(steps))
+	for i, step := range steps {
+		index, err := strconv.Atoi(step)
+		switch {
+		case i > 0 && err == nil && index >= 0 && index <= maxListIndex:
+			path[i] = segment{index: index, kind: indexSegment}
+		case bool(shouldCapitalize):
+			path[i] = segment{key: title(nameSegment(step)), kind: keySegment}
+		default:
+			path[i] = segment{key: step, kind: keySegment}
+		}
+	}
+	return path
+}
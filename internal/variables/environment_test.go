@@ -0,0 +1,54 @@
+package variables_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gomatic/renderizer/internal/constants"
+	"github.com/gomatic/renderizer/internal/variables"
+)
+
+func TestEnvironment(t *testing.T) {
+	t.Parallel()
+	names := map[string]string{
+		"NAME":             "web",
+		"ZIP":              "02134",
+		"DB__HOST":         "db.local",
+		"ITEMS__0":         "a",
+		"ITEMS__1":         "b",
+		"PODS__0__NAME":    "api",
+		"PODS__0__REPLICA": "3",
+	}
+
+	got, err := variables.Environment(names, true, typing)
+
+	require.NoError(t, err)
+	assert.Equal(t, variables.Context{
+		"Name":  "web",
+		"Zip":   int64(2134),
+		"Db":    map[string]any{"Host": "db.local"},
+		"Items": []any{"a", "b"},
+		"Pods":  []any{map[string]any{"Name": "api", "Replica": int64(3)}},
+	}, got)
+
+	literal, err := variables.Environment(map[string]string{"ZIP": "02134", "0": "x"}, false, variables.Typing{})
+	require.NoError(t, err)
+	assert.Equal(t, variables.Context{"ZIP": "02134", "0": "x"}, literal, "a leading number is a key, not an index")
+}
+
+func TestEnvironmentConflict(t *testing.T) {
+	t.Parallel()
+	_, err := variables.Environment(map[string]string{"DB": "x", "DB__HOST": "y"}, true, typing)
+	require.ErrorIs(t, err, constants.ErrMergeContext)
+}
+
+func TestEnvironmentPaths(t *testing.T) {
+	t.Parallel()
+	got := variables.EnvironmentPaths(map[string]string{"NAME": "x", "ITEMS__0__ID": "y"}, true)
+	assert.Equal(t, []variables.Path{
+		{Token: "ITEMS__0__ID", Names: []string{"Items"}, Index: 0},
+		{Token: "NAME", Names: []string{"Name"}, Index: 1},
+	}, got)
+}
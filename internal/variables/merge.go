@@ -0,0 +1,262 @@
+package variables
+
+import (
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/gomatic/renderizer/internal/constants"
+)
+
+// MergeStrategy decides how a value merged into a context combines with the
+// value already there. Maps always merge key by key; the strategy governs lists
+// and scalars.
+type MergeStrategy string
+
+// The merge strategies. They are named rather than spelled as literals at the
+// switches so the valid set has one definition, and an exhaustiveness check can
+// see it.
+const (
+	// MergeAppend concatenates lists and keeps an existing scalar: the
+	// historical behavior.
+	MergeAppend MergeStrategy = "append"
+	// MergeUniqueAppend concatenates lists, skipping elements already present,
+	// and keeps an existing scalar.
+	MergeUniqueAppend MergeStrategy = "unique-append"
+	// MergeReplace lets a later list or scalar replace the earlier one.
+	MergeReplace MergeStrategy = "replace"
+	// MergeDeepOverride merges lists index by index — maps within them
+	// recursively — and lets later scalars win.
+	MergeDeepOverride MergeStrategy = "deep-override"
+)
+
+// NormalizeMergeStrategy returns strategy when it is a known strategy and
+// MergeAppend otherwise.
+func NormalizeMergeStrategy(strategy MergeStrategy) MergeStrategy {
+	switch strategy {
+	case MergeAppend, MergeUniqueAppend, MergeReplace, MergeDeepOverride:
+		return strategy
+	}
+	return MergeAppend
+}
+
+// IsKnownMergeStrategy reports whether name is one of the merge strategies.
+func IsKnownMergeStrategy(name string) bool {
+	return NormalizeMergeStrategy(MergeStrategy(name)) == MergeStrategy(name)
+}
+
+// Directives overrides the merge strategy for individual key paths and
+// everything beneath them, as declared per key in a settings file. A nil
+// Directives overrides nothing.
+type Directives map[string]MergeStrategy
+
+// Set overrides the strategy for path.
+func (d Directives) Set(path []string, strategy MergeStrategy) {
+	d[directiveKey(path)] = strategy
+}
+
+// at returns the strategy in force at path: its own directive, or inherited.
+func (d Directives) at(path []string, inherited MergeStrategy) MergeStrategy {
+	if strategy, ok := d[directiveKey(path)]; ok {
+		return strategy
+	}
+	return inherited
+}
+
+// directiveKey joins a path unambiguously, even for keys containing dots.
+func directiveKey(path []string) string {
+	return strings.Join(path, "\x00")
+}
+
+// Merge merges src into dst under strategy, with directives overriding it per
+// key. A map meeting a non-map at the same key is a conflict no strategy can
+// resolve and fails with ErrMergeContext.
+func Merge(dst, src map[string]any, strategy MergeStrategy, directives Directives) error {
+	return mergeMaps(dst, src, nil, strategy, directives)
+}
+
+// mergeMaps merges each key of src into dst.
+func mergeMaps(dst, src map[string]any, path []string, strategy MergeStrategy, directives Directives) error {
+	for key, incoming := range src {
+		at := append(slices.Clone(path), key)
+		existing, present := dst[key]
+		if !present {
+			dst[key] = incoming
+			continue
+		}
+		merged, err := mergeValue(existing, incoming, at, directives.at(at, strategy), directives)
+		if err != nil {
+			return err
+		}
+		dst[key] = merged
+	}
+	return nil
+}
+
+// indexedList is a list built by an assignment to an explicit index: its
+// non-nil elements merge into the existing list at the same positions,
+// whatever the strategy, growing it as needed.
+type indexedList []any
+
+// appendedList is a list built by an assignment with `[]`: its elements are
+// appended to the existing list, whatever the strategy.
+type appendedList []any
+
+// mergeValue combines one existing value with its incoming counterpart.
+func mergeValue(existing, incoming any, path []string, strategy MergeStrategy, directives Directives) (any, error) {
+	switch positioned := incoming.(type) {
+	case indexedList:
+		return mergePositions(existing, positioned, path, strategy, directives)
+	case appendedList:
+		return appendElements(existing, positioned, path)
+	}
+	existingMap, existingIsMap := existing.(map[string]any)
+	incomingMap, incomingIsMap := incoming.(map[string]any)
+	switch {
+	case existingIsMap && incomingIsMap:
+		return existingMap, mergeMaps(existingMap, incomingMap, path, strategy, directives)
+	case existingIsMap || incomingIsMap:
+		return nil, constants.ErrMergeContext.With(nil, strings.Join(path, "."))
+	}
+	existingList, existingIsList := elements(existing)
+	incomingList, incomingIsList := incoming.([]any)
+	if existingIsList && incomingIsList {
+		return mergeLists(existingList, incomingList, path, strategy, directives)
+	}
+	return mergeScalar(existing, incoming, strategy), nil
+}
+
+// elements returns the elements of a list, whether plain or built by index or
+// append, and whether value is a list at all.
+func elements(value any) ([]any, bool) {
+	switch list := value.(type) {
+	case []any:
+		return list, true
+	case indexedList:
+		return list, true
+	case appendedList:
+		return list, true
+	}
+	return nil, false
+}
+
+// mergePositions merges each element of incoming into the existing list at
+// its index, leaving the positions incoming does not set untouched.
+func mergePositions(
+	existing any,
+	incoming indexedList,
+	path []string,
+	strategy MergeStrategy,
+	directives Directives,
+) (any, error) {
+	list, isList := elements(existing)
+	if !isList {
+		return nil, constants.ErrMergeContext.With(nil, strings.Join(path, "."))
+	}
+	for i, element := range incoming {
+		if element == nil {
+			continue
+		}
+		list = padded(list, i+1)
+		merged, err := mergeElement(list[i], element, path, strategy, directives)
+		if err != nil {
+			return nil, err
+		}
+		list[i] = merged
+	}
+	return indexedList(list), nil
+}
+
+// padded returns list extended with nil elements to at least length.
+func padded(list []any, length int) []any {
+	if len(list) >= length {
+		return list
+	}
+	return append(list, make([]any, length-len(list))...)
+}
+
+// mergeElement merges one incoming list element into the existing one, which
+// is nil where the list had no element yet.
+func mergeElement(existing, incoming any, path []string, strategy MergeStrategy, directives Directives) (any, error) {
+	if existing == nil {
+		return incoming, nil
+	}
+	return mergeValue(existing, incoming, path, strategy, directives)
+}
+
+// appendElements appends the incoming elements to the existing list.
+func appendElements(existing any, incoming appendedList, path []string) (any, error) {
+	list, isList := elements(existing)
+	if !isList {
+		return nil, constants.ErrMergeContext.With(nil, strings.Join(path, "."))
+	}
+	return appendedList(append(list, incoming...)), nil
+}
+
+// mergeLists combines two lists under strategy.
+func mergeLists(existing, incoming []any, path []string, strategy MergeStrategy, directives Directives) (any, error) {
+	switch strategy {
+	case MergeReplace:
+		return incoming, nil
+	case MergeDeepOverride:
+		return mergeIndexed(existing, incoming, path, directives)
+	case MergeUniqueAppend:
+		return appendUnique(existing, incoming), nil
+	case MergeAppend:
+	}
+	return append(existing, incoming...), nil
+}
+
+// mergeIndexed merges incoming into existing element by element, appending any
+// elements beyond the end of existing.
+func mergeIndexed(existing, incoming []any, path []string, directives Directives) (any, error) {
+	for i, element := range incoming {
+		if i >= len(existing) {
+			existing = append(existing, element)
+			continue
+		}
+		merged, err := mergeValue(existing[i], element, path, MergeDeepOverride, directives)
+		if err != nil {
+			return nil, err
+		}
+		existing[i] = merged
+	}
+	return existing, nil
+}
+
+// appendUnique appends each incoming element not already in existing.
+func appendUnique(existing, incoming []any) []any {
+	for _, element := range incoming {
+		if !slices.ContainsFunc(existing, func(present any) bool { return reflect.DeepEqual(present, element) }) {
+			existing = append(existing, element)
+		}
+	}
+	return existing
+}
+
+// mergeScalar resolves two non-map values where at least one is not a list.
+// The appending strategies keep the existing value unless it is empty (zero,
+// false, or ""), so a later file can still fill a blank; the overriding
+// strategies take the incoming value.
+func mergeScalar(existing, incoming any, strategy MergeStrategy) any {
+	switch strategy {
+	case MergeReplace, MergeDeepOverride:
+		return incoming
+	case MergeAppend, MergeUniqueAppend:
+	}
+	if existing == nil || reflect.ValueOf(existing).IsZero() {
+		return incoming
+	}
+	return existing
+}
+
+// IsOverriding reports whether strategy lets a later value win over an
+// earlier one, rather than keeping the earlier one.
+func IsOverriding(strategy MergeStrategy) bool {
+	switch strategy {
+	case MergeReplace, MergeDeepOverride:
+		return true
+	case MergeAppend, MergeUniqueAppend:
+	}
+	return false
+}
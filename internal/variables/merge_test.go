@@ -0,0 +1,112 @@
+package variables_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/gomatic/renderizer/internal/constants"
+	"github.com/gomatic/renderizer/internal/variables"
+)
+
+func TestMerge(t *testing.T) {
+	t.Parallel()
+	// Each case gets fresh maps: Merge mutates dst and may alias src's lists.
+	base := func() map[string]any {
+		return map[string]any{
+			"name":  "base",
+			"blank": "",
+			"items": []any{"a", "b"},
+			"list":  []any{map[string]any{"x": int64(1), "y": int64(1)}},
+			"deep":  map[string]any{"kept": "base"},
+		}
+	}
+	overlay := func() map[string]any {
+		return map[string]any{
+			"name":  "overlay",
+			"blank": "filled",
+			"items": []any{"b", "c", "d"},
+			"list":  []any{map[string]any{"y": int64(2)}, "extra"},
+			"deep":  map[string]any{"added": "overlay"},
+		}
+	}
+	deep := map[string]any{"kept": "base", "added": "overlay"}
+
+	tests := []struct {
+		want     map[string]any
+		strategy variables.MergeStrategy
+	}{
+		{
+			strategy: variables.MergeAppend,
+			want: map[string]any{
+				"name": "base", "blank": "filled", "items": []any{"a", "b", "b", "c", "d"},
+				"list": []any{map[string]any{"x": int64(1), "y": int64(1)}, map[string]any{"y": int64(2)}, "extra"},
+				"deep": deep,
+			},
+		},
+		{
+			strategy: variables.MergeUniqueAppend,
+			want: map[string]any{
+				"name": "base", "blank": "filled", "items": []any{"a", "b", "c", "d"},
+				"list": []any{map[string]any{"x": int64(1), "y": int64(1)}, map[string]any{"y": int64(2)}, "extra"},
+				"deep": deep,
+			},
+		},
+		{
+			strategy: variables.MergeReplace,
+			want: map[string]any{
+				"name": "overlay", "blank": "filled", "items": []any{"b", "c", "d"},
+				"list": []any{map[string]any{"y": int64(2)}, "extra"},
+				"deep": deep,
+			},
+		},
+		{
+			strategy: variables.MergeDeepOverride,
+			want: map[string]any{
+				"name": "overlay", "blank": "filled", "items": []any{"b", "c", "d"},
+				"list": []any{map[string]any{"x": int64(1), "y": int64(2)}, "extra"},
+				"deep": deep,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.strategy), func(t *testing.T) {
+			t.Parallel()
+			dst := base()
+			require.NoError(t, variables.Merge(dst, overlay(), tt.strategy, nil))
+			assert.Equal(t, tt.want, dst)
+		})
+	}
+}
+
+func TestMergeDirectiveOverridesTheStrategyBeneathItsKey(t *testing.T) {
+	t.Parallel()
+	dst := map[string]any{"a": map[string]any{"items": []any{1}}, "b": []any{1}}
+	src := map[string]any{"a": map[string]any{"items": []any{2}}, "b": []any{2}}
+	directives := variables.Directives{}
+	directives.Set([]string{"a"}, variables.MergeReplace)
+
+	require.NoError(t, variables.Merge(dst, src, variables.MergeAppend, directives))
+
+	assert.Equal(t, map[string]any{"a": map[string]any{"items": []any{2}}, "b": []any{1, 2}}, dst)
+}
+
+func TestMergeMapConflict(t *testing.T) {
+	t.Parallel()
+	for _, strategy := range []variables.MergeStrategy{variables.MergeAppend, variables.MergeReplace} {
+		err := variables.Merge(
+			map[string]any{"a": map[string]any{"b": 1}},
+			map[string]any{"a": []any{1}},
+			strategy, nil)
+		require.ErrorIs(t, err, constants.ErrMergeContext, "no strategy merges a map with a list")
+	}
+}
+
+func TestNormalizeMergeStrategy(t *testing.T) {
+	t.Parallel()
+	assert.Equal(t, variables.MergeReplace, variables.NormalizeMergeStrategy("replace"))
+	assert.Equal(t, variables.MergeAppend, variables.NormalizeMergeStrategy("bogus"))
+	assert.True(t, variables.IsKnownMergeStrategy("deep-override"))
+	assert.False(t, variables.IsKnownMergeStrategy("bogus"))
+}
@@ -0,0 +1,175 @@
+package variables
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gomatic/renderizer/internal/constants"
+)
+
+// The assignment path grammar: dot-separated keys, each optionally followed by
+// list subscripts — `[n]` sets element n, `[]` appends an element — and a key
+// in double quotes is taken literally, dots and all, and never capitalized:
+//
+//	--containers[0].name=web
+//	--args[]=--verbose
+//	--labels."app.kubernetes.io/name"=web
+
+// segmentKind distinguishes the steps of an assignment path.
+type segmentKind int
+
+const (
+	// keySegment is a map key, title-cased when capitalization is enabled.
+	keySegment segmentKind = iota
+	// quotedSegment is a map key given in quotes, used as written.
+	quotedSegment
+	// indexSegment is a list element at an explicit index.
+	indexSegment
+	// appendSegment is a new list element after the existing ones.
+	appendSegment
+)
+
+// segment is one step of an assignment path: a map key or a list subscript.
+type segment struct {
+	key   string
+	index int
+	kind  segmentKind
+}
+
+// maxListIndex bounds an explicit list index, so a typo cannot allocate a list
+// the size of memory.
+const maxListIndex = 1 << 16
+
+// The path punctuation.
+const (
+	keySeparator = '.'
+	quote        = '"'
+	openIndex    = '['
+	closeIndex   = ']'
+)
+
+// parsePath parses name into its segments, title-casing unquoted keys when
+// shouldCapitalize is set. A malformed name fails with ErrParseVariable.
+func parsePath(name variableName, shouldCapitalize Capitalization) ([]segment, error) {
+	segments, err := parseSegments(string(name), shouldCapitalize)
+	if err != nil {
+		return nil, constants.ErrParseVariable.With(err, name)
+	}
+	return segments, nil
+}
+
+// parseSegments parses each separated step of text in turn.
+func parseSegments(text string, shouldCapitalize Capitalization) ([]segment, error) {
+	var segments []segment
+	for {
+		steps, rest, err := parseStep(text, shouldCapitalize)
+		if err != nil {
+			return nil, err
+		}
+		segments = append(segments, steps...)
+		if rest == "" {
+			return segments, nil
+		}
+		if rest[0] != keySeparator {
+			return nil, fmt.Errorf("unexpected %q", rest)
+		}
+		text = rest[1:]
+	}
+}
+
+// parseStep parses the key at the start of text and the subscripts following
+// it, returning them and the text after them.
+func parseStep(text string, shouldCapitalize Capitalization) ([]segment, string, error) {
+	key, rest, err := parseKey(text, shouldCapitalize)
+	if err != nil {
+		return nil, "", err
+	}
+	steps := []segment{key}
+	for strings.HasPrefix(rest, string(openIndex)) {
+		var subscript segment
+		if subscript, rest, err = parseSubscript(rest); err != nil {
+			return nil, "", err
+		}
+		steps = append(steps, subscript)
+	}
+	return steps, rest, nil
+}
+
+// parseKey parses the key at the start of text — quoted, or bare up to the
+// next separator or subscript — returning it and the text after it.
+func parseKey(text string, shouldCapitalize Capitalization) (segment, string, error) {
+	if text != "" && text[0] == quote {
+		return parseQuotedKey(text)
+	}
+	end := strings.IndexAny(text, string([]byte{keySeparator, openIndex}))
+	if end < 0 {
+		end = len(text)
+	}
+	key := text[:end]
+	if bool(shouldCapitalize) {
+		key = title(nameSegment(key))
+	}
+	return segment{key: key, kind: keySegment}, text[end:], nil
+}
+
+// parseQuotedKey parses the Go-quoted key at the start of text.
+func parseQuotedKey(text string) (segment, string, error) {
+	quoted, err := strconv.QuotedPrefix(text)
+	if err != nil {
+		return segment{}, "", errors.New("unterminated quoted key")
+	}
+	key, err := strconv.Unquote(quoted)
+	if err != nil {
+		return segment{}, "", err
+	}
+	return segment{key: key, kind: quotedSegment}, text[len(quoted):], nil
+}
+
+// parseSubscript parses the `[n]` or `[]` at the start of text, returning it
+// and the text after it.
+func parseSubscript(text string) (segment, string, error) {
+	inner, rest, found := strings.Cut(text[1:], string(closeIndex))
+	if !found {
+		return segment{}, "", errors.New("unterminated index")
+	}
+	if inner == "" {
+		return segment{kind: appendSegment}, rest, nil
+	}
+	index, err := strconv.Atoi(inner)
+	if err != nil || index < 0 || index > maxListIndex {
+		return segment{}, "", errors.New("invalid index " + strconv.Quote(inner))
+	}
+	return segment{index: index, kind: indexSegment}, rest, nil
+}
+
+// keys returns the map keys of path up to its first subscript: the leaf a
+// value is attributed to, since a list is attributed as a whole.
+func keys(path []segment) []string {
+	names := make([]string, 0, len(path))
+	for _, step := range path {
+		if step.kind == indexSegment || step.kind == appendSegment {
+			break
+		}
+		names = append(names, step.key)
+	}
+	return names
+}
+
+// cutUnquoted splits text around the first sep outside double quotes, the way
+// strings.Cut does, so a quoted key may contain `=` or `:`.
+func cutUnquoted(text string, sep byte) (string, string, bool) {
+	isQuoted := false
+	for i := 0; i < len(text); i++ {
+		switch {
+		case isQuoted && text[i] == '\\':
+			i++ // the escaped character cannot close the quote
+		case text[i] == quote:
+			isQuoted = !isQuoted
+		case !isQuoted && text[i] == sep:
+			return text[:i], text[i+1:], true
+		}
+	}
+	return text, "", false
+}
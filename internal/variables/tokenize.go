@@ -1,6 +1,9 @@
 package variables
 
-import "strings"
+import (
+	"slices"
+	"strings"
+)
 
 // capitalizeToggle is the positional flag that flips capitalization of the
 // variable names that follow it.
@@ -16,33 +19,52 @@ type Tokens struct {
 	Assignments []string
 }
 
-type (
-	// argument is one raw command-line argument under classification.
-	argument string
-	// flagName is a long flag's name, dashes and value stripped.
-	flagName string
-)
+// argument is one raw command-line argument under classification.
+type argument string
+
+// Flags are the long flag names of a command, long aliases included, and the
+// flags of each of its subcommands by name: the names that must reach
+// urfave/cli rather than become variables.
+type Flags struct {
+	Subcommands map[string]Flags
+	Long        []string
+}
 
 // Tokenize splits args (excluding the program name) into urfave/cli arguments
 // and arbitrary variable assignments. Only long `--name[=value]` flags that are
-// not renderizer's own, plus the positional `-C` toggle, are treated as
-// assignments; everything else — known flags (long or short), templates, and
-// subcommands — passes through to urfave/cli.
-func Tokenize(args []string) Tokens {
+// not among the long flags of the command being run, plus the positional `-C`
+// toggle, are treated as assignments; everything else — known flags (long or
+// short), templates, and subcommands — passes through to urfave/cli. An
+// argument naming a subcommand of flags makes that subcommand's flags known
+// from then on, along with its parent's, which urfave/cli's subcommands accept
+// too.
+func Tokenize(args []string, flags Flags) Tokens {
 	tokens := Tokens{}
 	for _, arg := range args {
-		if isAssignment(argument(arg)) {
+		if isAssignment(argument(arg), flags) {
 			tokens.Assignments = append(tokens.Assignments, arg)
 			continue
 		}
 		tokens.Args = append(tokens.Args, arg)
+		flags = flags.after(arg)
 	}
 	return tokens
 }
 
+// after returns the flags known once arg has passed through: those of the
+// subcommand it names, with these, or these.
+func (f Flags) after(arg string) Flags {
+	subcommand, ok := f.Subcommands[arg]
+	if !ok {
+		return f
+	}
+	subcommand.Long = append(slices.Clip(f.Long), subcommand.Long...)
+	return subcommand
+}
+
 // isAssignment reports whether arg is an arbitrary variable assignment: the -C
-// toggle, or a long flag whose name is not one of renderizer's own.
-func isAssignment(arg argument) bool {
+// toggle, or a long flag whose name is not one of flags.
+func isAssignment(arg argument, flags Flags) bool {
 	if string(arg) == capitalizeToggle {
 		return true
 	}
@@ -50,16 +72,5 @@ func isAssignment(arg argument) bool {
 		return false
 	}
 	key, _, _ := strings.Cut(strings.TrimPrefix(string(arg), "--"), "=")
-	return !knownLongFlag(flagName(key))
-}
-
-// knownLongFlag reports whether key is one of renderizer's own long flags (or
-// long aliases), which must reach urfave/cli rather than become a variable.
-func knownLongFlag(key flagName) bool {
-	switch key {
-	case "settings", "missing", "environment", "env",
-		"stdin", "testing", "debugging", "debug", "verbose", "help", "version":
-		return true
-	}
-	return false
+	return !slices.Contains(flags.Long, key)
 }
@@ -8,6 +8,15 @@ import (
 	"github.com/gomatic/renderizer/internal/variables"
 )
 
+// flags are the long flags of a command tree like renderizer's.
+var flags = variables.Flags{
+	Long: []string{"settings", "profile", "merge", "explain", "verbose", "debugging", "debug", "help"},
+	Subcommands: map[string]variables.Flags{
+		"analyze": {},
+		"test":    {Long: []string{"update"}},
+	},
+}
+
 func TestTokenize(t *testing.T) {
 	t.Parallel()
 	tests := []struct {
@@ -44,6 +53,21 @@ func TestTokenize(t *testing.T) {
 			args:    []string{"--debug"},
 			cliArgs: []string{"--debug"},
 		},
+		{
+			name:    "explain flag passes through",
+			args:    []string{"--explain"},
+			cliArgs: []string{"--explain"},
+		},
+		{
+			name:    "profile flag passes through",
+			args:    []string{"--profile", "prod"},
+			cliArgs: []string{"--profile", "prod"},
+		},
+		{
+			name:    "merge flag passes through",
+			args:    []string{"--merge=replace"},
+			cliArgs: []string{"--merge=replace"},
+		},
 		{
 			name:    "known value long flag with equals",
 			args:    []string{"--settings=a.yaml"},
@@ -70,12 +94,30 @@ func TestTokenize(t *testing.T) {
 			cliArgs: []string{"--verbose", "t.tmpl", "--settings", "s.yaml"},
 			assigns: []string{"--name=World", "-C"},
 		},
+		{
+			name:    "a subcommand's flag is a variable of the command",
+			args:    []string{"--update=x", "t.tmpl"},
+			cliArgs: []string{"t.tmpl"},
+			assigns: []string{"--update=x"},
+		},
+		{
+			name:    "a subcommand's flags pass through after it, with its parent's",
+			args:    []string{"--update=x", "test", "--update", "--verbose", "--name=World", "dir"},
+			cliArgs: []string{"test", "--update", "--verbose", "dir"},
+			assigns: []string{"--update=x", "--name=World"},
+		},
+		{
+			name:    "another subcommand's flags are variables",
+			args:    []string{"analyze", "--update", "t.tmpl"},
+			cliArgs: []string{"analyze", "t.tmpl"},
+			assigns: []string{"--update"},
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
-			got := variables.Tokenize(tt.args)
+			got := variables.Tokenize(tt.args, flags)
 			assert.Equal(t, tt.cliArgs, got.Args)
 			assert.Equal(t, tt.assigns, got.Assignments)
 		})
@@ -1,13 +1,112 @@
 package variables
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
 	"strconv"
+	"strings"
 	"time"
+
+	"gopkg.in/yaml.v3"
 )
 
 // TimeFormat is the layout used to recognize a string value as a time.Time.
 type TimeFormat string
 
+// Inference, when true, guesses the type of untyped text (see typed); when
+// false, text stays a string unless a type is given explicitly. Guessing is
+// the historical behavior, and the source of values like `--zip=02134`
+// becoming the integer 2134.
+type Inference bool
+
+// ReadFile reads a named file's bytes, for `@file` values. os.ReadFile
+// satisfies it in production; tests inject a fake.
+type ReadFile func(name string) ([]byte, error)
+
+// Typing is how assignment values are typed: Format recognizes times,
+// ShouldInfer decides whether untyped text is guessed at, and Read loads the
+// `@file` a structured value may name instead of giving it inline.
+type Typing struct {
+	Read        ReadFile
+	Format      TimeFormat
+	ShouldInfer Inference
+}
+
+// valueType is an explicit type suffix on an assignment's name, as in
+// `--port:int=80`.
+type valueType string
+
+// The explicit value types. inferredType is the absence of a suffix.
+const (
+	inferredType valueType = ""
+	stringType   valueType = "string"
+	intType      valueType = "int"
+	floatType    valueType = "float"
+	boolType     valueType = "bool"
+	timeType     valueType = "time"
+	jsonType     valueType = "json"
+	yamlType     valueType = "yaml"
+)
+
+// fileReference prefixes a structured value that names a file to read it from.
+const fileReference = "@"
+
+// explicit coerces raw text to the type its suffix names, failing when the
+// text is not of that type. A json or yaml value is decoded — from the file it
+// names when it starts with "@" — and its strings are kept as given.
+func explicit(kind valueType, raw rawText, typing Typing) (any, error) {
+	text := string(raw)
+	switch kind {
+	case inferredType:
+		return inferred(typing, raw), nil
+	case stringType:
+		return text, nil
+	case intType:
+		return strconv.ParseInt(text, 10, 64)
+	case floatType:
+		return strconv.ParseFloat(text, 64)
+	case boolType:
+		return strconv.ParseBool(text)
+	case timeType:
+		return time.Parse(string(typing.Format), text)
+	case jsonType, yamlType:
+		return structured(kind, raw, typing)
+	}
+	return nil, fmt.Errorf("unknown type %q", kind)
+}
+
+// structured decodes a json or yaml value, inline or from the file it names.
+// JSON is decoded as the YAML it is a subset of, once it is known to be JSON,
+// so numbers come out as integers where they can rather than as float64.
+func structured(kind valueType, raw rawText, typing Typing) (any, error) {
+	data := []byte(raw)
+	if name, isFile := strings.CutPrefix(string(raw), fileReference); isFile {
+		read, err := typing.Read(name)
+		if err != nil {
+			return nil, err
+		}
+		data = read
+	}
+	if kind == jsonType && !json.Valid(data) {
+		return nil, errors.New("invalid JSON")
+	}
+	var value any
+	if err := yaml.Unmarshal(data, &value); err != nil {
+		return nil, err
+	}
+	return retypeValue(value, typing.Format, false, false), nil
+}
+
+// inferred types raw text by guessing, or keeps it a string when inference is
+// off.
+func inferred(typing Typing, raw rawText) any {
+	if !bool(typing.ShouldInfer) {
+		return string(raw)
+	}
+	return typed(typing.Format, raw)
+}
+
 // rawText is the untyped textual form of a variable value — from a
 // command-line assignment or a settings file — before coercion.
 type rawText string
@@ -38,26 +137,38 @@ func typed(format TimeFormat, raw rawText) any {
 type CollapseSingles bool
 
 // Retype walks a decoded map and coerces every leaf to its specific type:
-// untyped strings become typed values, ints widen to int64, and (when
-// shouldCollapse is set) single-element slices unwrap to their element. It
-// mutates and returns source.
-func Retype(source map[string]any, format TimeFormat, shouldCollapse CollapseSingles) map[string]any {
+// untyped strings become typed values (when shouldInfer is set), ints widen to
+// int64, and (when shouldCollapse is set) single-element slices held by a map
+// key unwrap to their element. It mutates and returns source.
+func Retype(
+	source map[string]any,
+	format TimeFormat,
+	shouldCollapse CollapseSingles,
+	shouldInfer Inference,
+) map[string]any {
 	for key, value := range source {
-		source[key] = retypeValue(value, format, shouldCollapse)
+		source[key] = retypeValue(value, format, shouldCollapse, shouldInfer)
 	}
 	return source
 }
 
 // retypeValue coerces a single decoded value, recursing into maps and slices.
-func retypeValue(value any, format TimeFormat, shouldCollapse CollapseSingles) any {
+func retypeValue(value any, format TimeFormat, shouldCollapse CollapseSingles, shouldInfer Inference) any {
 	switch typedValue := value.(type) {
 	case map[string]any:
-		return Retype(typedValue, format, shouldCollapse)
+		return Retype(typedValue, format, shouldCollapse, shouldInfer)
 	case []any:
-		return retypeSlice(typedValue, format, shouldCollapse)
+		return retypeSlice(typedValue, format, shouldCollapse, shouldInfer)
+	case indexedList:
+		return retypeElements(typedValue, format, shouldCollapse, shouldInfer)
+	case appendedList:
+		return retypeElements(typedValue, format, shouldCollapse, shouldInfer)
 	case int:
 		return int64(typedValue)
 	case string:
+		if !bool(shouldInfer) {
+			return typedValue
+		}
 		return typed(format, rawText(typedValue))
 	default:
 		return value
@@ -65,13 +176,24 @@ func retypeValue(value any, format TimeFormat, shouldCollapse CollapseSingles) a
 }
 
 // retypeSlice coerces each element, collapsing a single-element slice to its
-// element when shouldCollapse is set.
-func retypeSlice(slice []any, format TimeFormat, shouldCollapse CollapseSingles) any {
+// element when shouldCollapse is set. Only the slice itself collapses: its
+// elements are values as given — a one-element JSON list stays a list.
+func retypeSlice(slice []any, format TimeFormat, shouldCollapse CollapseSingles, shouldInfer Inference) any {
 	if bool(shouldCollapse) && len(slice) == 1 {
-		return retypeValue(slice[0], format, shouldCollapse)
+		return retypeValue(slice[0], format, false, shouldInfer)
 	}
 	for i, element := range slice {
-		slice[i] = retypeValue(element, format, shouldCollapse)
+		slice[i] = retypeValue(element, format, false, shouldInfer)
 	}
 	return slice
 }
+
+// retypeElements turns a list built by index or append into a plain one. It
+// never collapses, being a list by construction, but its elements do: each
+// holds what an assignment set, just as a map value does.
+func retypeElements(list []any, format TimeFormat, shouldCollapse CollapseSingles, shouldInfer Inference) []any {
+	for i, element := range list {
+		list[i] = retypeValue(element, format, shouldCollapse, shouldInfer)
+	}
+	return list
+}
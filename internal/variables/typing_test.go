@@ -18,7 +18,7 @@ func TestRetype(t *testing.T) {
 		"multi":   []any{"x", "y"},
 		"single":  []any{"only"},
 	}
-	got := variables.Retype(source, timeFormat, true)
+	got := variables.Retype(source, timeFormat, true, true)
 	assert.Equal(t, int64(7), got["int"])
 	assert.Equal(t, "hello", got["str"])
 	assert.Equal(t, true, got["boolean"])
@@ -29,7 +29,7 @@ func TestRetype(t *testing.T) {
 
 func TestRetypeNoCollapse(t *testing.T) {
 	t.Parallel()
-	got := variables.Retype(map[string]any{"single": []any{"only"}}, timeFormat, false)
+	got := variables.Retype(map[string]any{"single": []any{"only"}}, timeFormat, false, true)
 	assert.Equal(t, []any{"only"}, got["single"])
 }
 
@@ -52,13 +52,28 @@ func TestCollapseSinglesRestoresAScalarGivenExactlyOnce(t *testing.T) {
 		}
 	}
 
-	collapsed := variables.Retype(fresh(), "20060102", variables.CollapseSingles(true))
+	collapsed := variables.Retype(fresh(), "20060102", variables.CollapseSingles(true), true)
 
 	assert.Equal(t, "solo", collapsed["once"], "a name given exactly once becomes its lone element")
 	assert.Equal(t, []any{"first", "second"}, collapsed["twice"], "a repeated name stays a list")
 	assert.Equal(t, []any{}, collapsed["none"], "an empty list has no lone element to become")
 	assert.Equal(t, "already scalar", collapsed["plain"])
 
-	kept := variables.Retype(fresh(), "20060102", variables.CollapseSingles(false))
+	kept := variables.Retype(fresh(), "20060102", variables.CollapseSingles(false), true)
 	assert.Equal(t, []any{"solo"}, kept["once"], "with collapsing off the slice is preserved as given")
 }
+
+func TestRetypeWithoutInferenceKeepsStrings(t *testing.T) {
+	t.Parallel()
+	got := variables.Retype(map[string]any{
+		"zip":    "02134",
+		"nested": map[string]any{"on": "true"},
+		"port":   80,
+	}, timeFormat, false, false)
+
+	assert.Equal(t, map[string]any{
+		"zip":    "02134",
+		"nested": map[string]any{"on": "true"},
+		"port":   int64(80),
+	}, got)
+}
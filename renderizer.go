@@ -6,8 +6,11 @@
 package renderizer
 
 import (
+	"context"
 	"text/template"
+	"time"
 
+	"github.com/gomatic/renderizer/internal/constants"
 	"github.com/gomatic/renderizer/internal/inspect"
 	tmpl "github.com/gomatic/renderizer/internal/template"
 )
@@ -22,6 +25,46 @@ type (
 	Template []byte
 )
 
+// TemplateError locates a failed render or analysis in its template: match it
+// with errors.As for the template name, line, column, source line, and field
+// path the failure was reported at.
+type TemplateError = constants.TemplateError
+
+// The failures of a render that ran past its Limits, and of an HTML render
+// that could not be escaped; match them with errors.Is.
+const (
+	ErrEscapeTemplate = constants.ErrEscapeTemplate
+	ErrRenderTimeout  = constants.ErrRenderTimeout
+	ErrOutputLimit    = constants.ErrOutputLimit
+	ErrIterationLimit = constants.ErrIterationLimit
+)
+
+// Limits bounds a render of an untrusted or buggy template: the time it may
+// take, the bytes it may write, and the iterations its range actions may run
+// in all. A zero field sets no bound.
+type Limits struct {
+	Timeout        time.Duration
+	MaxOutputBytes int64
+	MaxIterations  int64
+}
+
+// Delims are the action delimiters of a template, such as "[[" and "]]" for
+// one whose output uses {{ }} itself. The zero value is {{ and }}.
+type Delims struct {
+	Left  string
+	Right string
+}
+
+// Options are the optional settings of RenderWith and AnalyzeWith. The zero
+// value renders as Render does. IsHTML parses with html/template, escaping
+// every action for the HTML context it lands in; an action it cannot escape
+// fails with ErrEscapeTemplate.
+type Options struct {
+	Delims Delims
+	Limits Limits
+	IsHTML bool
+}
+
 // Funcs returns a fresh standard function set: Sprig v3 overlaid by
 // gomatic/funcmap. Callers may add their own functions to the returned map
 // before rendering or analyzing.
@@ -29,19 +72,78 @@ func Funcs() template.FuncMap {
 	return tmpl.Funcs(false)
 }
 
+// SandboxFuncs returns the sandboxed function set for rendering untrusted
+// templates: Funcs restricted to the documented allowlist of functions whose
+// result depends on their arguments alone, plus the standard functions allow
+// names, minus those deny names. A template calling a removed function fails
+// to parse, reporting the function as not allowed. Bind no environment into
+// the data to complete the sandbox.
+func SandboxFuncs(allow, deny []string) template.FuncMap {
+	policy := tmpl.FuncPolicy{Allow: allow, Deny: deny, IsSandboxed: true}
+	return policy.Apply(tmpl.Funcs(false))
+}
+
 // Render parses source (labeled name) with funcs and the missingkey option,
 // executes it against data, and returns the rendered bytes.
 func Render(funcs template.FuncMap, missing MissingKey, name Name, source Template, data any) ([]byte, error) {
-	return tmpl.Render(funcs, tmpl.NormalizeMissingKey(tmpl.MissingKey(missing)), tmpl.Name(name), source, data)
+	return RenderWith(context.Background(), Options{}, funcs, missing, name, source, data)
+}
+
+// RenderLimited renders as Render does within limits, failing with
+// ErrRenderTimeout, ErrOutputLimit, or ErrIterationLimit once the render runs
+// past one, and with the cause of the cancellation once ctx is cancelled.
+func RenderLimited(
+	ctx context.Context,
+	limits Limits,
+	funcs template.FuncMap,
+	missing MissingKey,
+	name Name,
+	source Template,
+	data any,
+) ([]byte, error) {
+	return RenderWith(ctx, Options{Limits: limits}, funcs, missing, name, source, data)
+}
+
+// RenderWith renders as Render does with the given options: parsing with
+// their delimiters, as HTML when they say so, and failing as RenderLimited
+// does past their limits.
+func RenderWith(
+	ctx context.Context,
+	options Options,
+	funcs template.FuncMap,
+	missing MissingKey,
+	name Name,
+	source Template,
+	data any,
+) ([]byte, error) {
+	bounds := tmpl.Limits{
+		Timeout:       tmpl.Timeout(options.Limits.Timeout),
+		MaxOutput:     tmpl.OutputBytes(options.Limits.MaxOutputBytes),
+		MaxIterations: tmpl.Iterations(options.Limits.MaxIterations),
+	}
+	return tmpl.RenderLimited(ctx, bounds, funcs, tmpl.NormalizeMissingKey(tmpl.MissingKey(missing)),
+		syntax(options), tmpl.Name(name), source, data)
 }
 
 // Analyze infers the input data a template requires and returns it as a YAML
 // skeleton (scalars "", ranged values single-element lists, nested fields maps).
 // funcs must contain every function the template calls so it parses.
 func Analyze(funcs template.FuncMap, name Name, source Template) ([]byte, error) {
-	model, err := inspect.Analyze(funcs, inspect.Name(name), source)
+	return AnalyzeWith(Options{}, funcs, name, source)
+}
+
+// AnalyzeWith analyzes as Analyze does, parsing with the delimiters of
+// options, and as HTML when they say so; their limits do not apply, since
+// analysis executes nothing.
+func AnalyzeWith(options Options, funcs template.FuncMap, name Name, source Template) ([]byte, error) {
+	model, err := inspect.Analyze(funcs, syntax(options), inspect.Name(name), source)
 	if err != nil {
 		return nil, err
 	}
 	return inspect.Skeleton(model), nil
 }
+
+// syntax returns how options parse a template.
+func syntax(options Options) tmpl.Syntax {
+	return tmpl.Syntax{Delims: tmpl.Delims(options.Delims), IsHTML: tmpl.HTMLEnabled(options.IsHTML)}
+}
@@ -1,6 +1,8 @@
 package renderizer_test
 
 import (
+	"context"
+	"errors"
 	"strings"
 	"testing"
 
@@ -50,3 +52,63 @@ func TestAnalyzeError(t *testing.T) {
 		t.Fatal("Analyze expected parse error")
 	}
 }
+
+func TestSandboxFuncs(t *testing.T) {
+	funcs := renderizer.SandboxFuncs([]string{"now"}, []string{"upper"})
+	for name, want := range map[string]bool{"lower": true, "now": true, "upper": false, "env": false} {
+		if _, ok := funcs[name]; ok != want {
+			t.Errorf("SandboxFuncs has %q = %v, want %v", name, ok, want)
+		}
+	}
+
+	_, err := renderizer.Render(funcs, "error", "t", []byte(`{{ env "HOME" }}`), nil)
+	var located *renderizer.TemplateError
+	if !errors.As(err, &located) || !strings.Contains(located.Reason, "not in the allowed function set") {
+		t.Fatalf("Render of a removed function = %v", err)
+	}
+}
+
+func TestRenderLimited(t *testing.T) {
+	limits := renderizer.Limits{MaxIterations: 10}
+	_, err := renderizer.RenderLimited(context.Background(), limits, renderizer.Funcs(), "error", "t",
+		[]byte(`{{ range until 1000 }}{{ end }}`), nil)
+	if !errors.Is(err, renderizer.ErrIterationLimit) {
+		t.Fatalf("RenderLimited error = %v, want the iteration limit", err)
+	}
+}
+
+func TestRenderWithDelims(t *testing.T) {
+	options := renderizer.Options{Delims: renderizer.Delims{Left: "[[", Right: "]]"}}
+	out, err := renderizer.RenderWith(context.Background(), options, renderizer.Funcs(), "error", "t",
+		[]byte(`[[ .Name ]] {{ .Kept }}`), map[string]any{"Name": "x"})
+	if err != nil {
+		t.Fatalf("RenderWith error: %v", err)
+	}
+	if string(out) != "x {{ .Kept }}" {
+		t.Fatalf("RenderWith = %q", out)
+	}
+	skeleton, err := renderizer.AnalyzeWith(options, renderizer.Funcs(), "t", []byte(`[[ .Name ]] {{ .Kept }}`))
+	if err != nil {
+		t.Fatalf("AnalyzeWith error: %v", err)
+	}
+	if !strings.Contains(string(skeleton), "Name") || strings.Contains(string(skeleton), "Kept") {
+		t.Fatalf("AnalyzeWith skeleton = %q", skeleton)
+	}
+}
+
+func TestRenderWithHTML(t *testing.T) {
+	options := renderizer.Options{IsHTML: true}
+	out, err := renderizer.RenderWith(context.Background(), options, renderizer.Funcs(), "error", "t",
+		[]byte(`<p>{{ .Name }}</p>`), map[string]any{"Name": "<b>"})
+	if err != nil {
+		t.Fatalf("RenderWith error: %v", err)
+	}
+	if string(out) != "<p>&lt;b&gt;</p>" {
+		t.Fatalf("RenderWith = %q", out)
+	}
+	_, err = renderizer.RenderWith(context.Background(), options, renderizer.Funcs(), "error", "t",
+		[]byte(`<a {{ if .Name }}href{{ end }}="x">`), map[string]any{"Name": "x"})
+	if !errors.Is(err, renderizer.ErrEscapeTemplate) {
+		t.Fatalf("RenderWith error = %v, want an escape failure", err)
+	}
+}